@@ -1,25 +1,50 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
+	"github.com/jaeha-choi/DFF/internal/control"
 	"github.com/jaeha-choi/DFF/internal/core"
+	"github.com/jaeha-choi/DFF/internal/gui"
+	"github.com/jaeha-choi/DFF/internal/headless"
+	"github.com/jaeha-choi/DFF/internal/locale"
 	"github.com/jaeha-choi/DFF/internal/updater"
 	"github.com/jaeha-choi/DFF/pkg/log"
+	"io"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 func main() {
 	var logOut *os.File
 
+	headlessMode := flag.Bool("headless", false,
+		"Run without a window. Roles, rune pages and item sets can be picked, and the build saved as the default, "+
+			"by typing commands to the standard input.")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Args()))
+	}
+
 	logOut, err := os.OpenFile("dff.log", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
 	if err != nil {
 		logOut = os.Stdout
 	}
 
+	if *headlessMode {
+		runHeadless(logOut)
+		return
+	}
+
 	client := core.Initialize(logOut)
 
 	// Does not seem to work because of w.ShowAndRun()?
@@ -29,7 +54,20 @@ func main() {
 	//	_ = client.WriteConfig()
 	//}()
 
+	// Untranslated text is shown in English
+	tr, _ := locale.Load(client.Language)
+
 	a := app.New()
+	// The default font does not have CJK characters, so other languages use the embedded Hangul font
+	// unless font_path overrides it
+	if fontPath := client.FontPath; fontPath != "" || tr.Language() != locale.English {
+		if fontTheme, err := gui.NewFontTheme(fontPath); err != nil {
+			client.Log.Debug(err)
+			client.Log.Error("Error while loading font: ", fontPath)
+		} else {
+			a.Settings().SetTheme(fontTheme)
+		}
+	}
 	w := a.NewWindow(core.ProjectName + " " + core.Version)
 
 	w.SetOnClosed(func() {
@@ -50,32 +88,45 @@ func main() {
 		client.Interval = f
 	}
 
+	// Not monospace, as only regular text uses the configured font
 	infoTextStyle := fyne.TextStyle{
-		Bold:      true,
-		Italic:    false,
-		Monospace: true,
+		Bold:   true,
+		Italic: false,
 	}
-	status := widget.NewLabelWithStyle("Not running", fyne.TextAlignCenter, infoTextStyle)
-	selectedChamp := widget.NewLabelWithStyle("Not selected", fyne.TextAlignCenter, infoTextStyle)
+	status := widget.NewLabelWithStyle(tr.T("Not running"), fyne.TextAlignCenter, infoTextStyle)
+	selectedChamp := widget.NewLabelWithStyle(tr.T("Not selected"), fyne.TextAlignCenter, infoTextStyle)
 
 	enableRunesCheck := widget.NewCheck("", func(b bool) {
-		client.EnableRune = b
+		features := client.Features()
+		features.EnableRune = b
+		client.SetFeatures(features)
 	})
 	enableRunesCheck.SetChecked(client.EnableRune)
 
 	enableItemsCheck := widget.NewCheck("", func(b bool) {
-		client.EnableItem = b
+		features := client.Features()
+		features.EnableItem = b
+		client.SetFeatures(features)
 	})
 	enableItemsCheck.SetChecked(client.EnableItem)
 
 	roleSelect := widget.NewSelect(nil, nil)
-	roleSelect.PlaceHolder = "No champion selected"
+	roleSelect.PlaceHolder = tr.T("No champion selected")
 
 	runeSelect := widget.NewSelect(nil, nil)
-	runeSelect.PlaceHolder = "No rune selected"
+	runeSelect.PlaceHolder = tr.T("No rune selected")
+
+	itemSelect := widget.NewSelect(nil, nil)
+	itemSelect.PlaceHolder = tr.T("No item set selected")
+
+	skillSelect := widget.NewSelect(nil, nil)
+	skillSelect.PlaceHolder = tr.T("No skill order available")
+	skillGrid := widget.NewLabelWithStyle(gui.SkillGrid(nil), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
 
 	enableSpellCheck := widget.NewCheck("", func(b bool) {
-		client.EnableSpell = b
+		features := client.Features()
+		features.EnableSpell = b
+		client.SetFeatures(features)
 	})
 	enableSpellCheck.SetChecked(client.EnableSpell)
 
@@ -95,38 +146,138 @@ func main() {
 	})
 	enableDebugging.SetChecked(client.Debug)
 
-	checkUpdateButton := widget.NewButton("Check Update", func() {
+	checkUpdateButton := widget.NewButton(tr.T("Check Update"), func() {
 		updater.Update(client.Log, w)
 	})
 
+	saveDefaultButton := widget.NewButton(tr.T("Save current as my default"), func() {
+		if err := client.SaveCurrentAsDefault(); err != nil {
+			dialog.ShowError(err, w)
+		}
+	})
+
+	observer := withControl(client, gui.NewObserver(w, tr, status, selectedChamp, roleSelect, runeSelect, itemSelect, skillSelect, skillGrid))
 	go func() {
 		for {
-			client.Run(w, status, roleSelect, selectedChamp, runeSelect)
+			client.Run(observer)
 		}
 	}()
 
 	left := container.NewVBox(
 		widget.NewLabel(core.ProjectName+" "+core.Version),
-		widget.NewLabel("Program Status:"),
+		widget.NewLabel(tr.T("Program Status:")),
 		status,
-		widget.NewLabel("Current Champion:"),
+		widget.NewLabel(tr.T("Current Champion:")),
 		selectedChamp,
 	)
 	right := container.NewVBox(
 		checkUpdateButton,
-		container.NewHBox(widget.NewLabel("Debug"), enableDebugging),
-		container.NewHBox(widget.NewLabel("Auto runes"), enableRunesCheck),
-		container.NewHBox(widget.NewLabel("Auto items"), enableItemsCheck),
-		container.NewHBox(widget.NewLabel("Auto spells"), enableSpellCheck),
-		container.NewHBox(widget.NewLabel("Left Flash"), enableDFlash),
-		widget.NewLabel("Polling interval"),
+		container.NewHBox(widget.NewLabel(tr.T("Debug")), enableDebugging),
+		container.NewHBox(widget.NewLabel(tr.T("Auto runes")), enableRunesCheck),
+		container.NewHBox(widget.NewLabel(tr.T("Auto items")), enableItemsCheck),
+		container.NewHBox(widget.NewLabel(tr.T("Auto spells")), enableSpellCheck),
+		container.NewHBox(widget.NewLabel(tr.T("Left Flash")), enableDFlash),
+		widget.NewLabel(tr.T("Polling interval")),
 		sl,
 	)
-	bottom := container.NewVBox(roleSelect, runeSelect)
+	bottom := container.NewVBox(roleSelect, runeSelect, itemSelect, saveDefaultButton, skillSelect, skillGrid)
 
 	w.SetContent(container.New(layout.NewBorderLayout(nil, bottom, left, right), bottom, left, right))
 
-	w.Resize(fyne.NewSize(350, 400))
+	w.Resize(fyne.NewSize(480, 560))
 	w.SetFixedSize(true)
 	w.ShowAndRun()
 }
+
+// runHeadless runs DFF without a window, logging to both logOut and the standard output
+func runHeadless(logOut *os.File) {
+	var out io.Writer = logOut
+	if logOut != os.Stdout {
+		out = io.MultiWriter(logOut, os.Stdout)
+	}
+
+	client := core.Initialize(out)
+	headlessObserver := headless.NewObserver(client.Log)
+	headlessObserver.SetSaveHandler(func() {
+		_ = client.SaveCurrentAsDefault()
+	})
+	go headlessObserver.Listen(os.Stdin)
+	observer := withControl(client, headlessObserver)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		client.Log.Debug("Saving configuration")
+		if err := client.WriteConfig(); err != nil {
+			client.Log.Debug(err)
+			client.Log.Errorf("Error while writing configuration")
+		}
+		client.Log.Debug("Exiting...")
+		logOut.Close()
+		os.Exit(0)
+	}()
+
+	for {
+		client.Run(observer)
+	}
+}
+
+// withControl starts the control API if it is enabled, and returns an Observer which updates both the API and observer
+func withControl(client *core.DFFClient, observer core.Observer) core.Observer {
+	if client.ControlAddr == "" {
+		return observer
+	}
+
+	server := control.NewServer(client, observer, client.Log)
+	go func() {
+		if err := server.ListenAndServe(client.ControlAddr); err != nil {
+			client.Log.Debug(err)
+			client.Log.Error("Error while starting control API")
+		}
+	}()
+	return server
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	_, _ = fmt.Fprintf(out, "Usage: %s [--headless]\n", os.Args[0])
+	_, _ = fmt.Fprintf(out, "       %s cache export <file>\tExport cache as JSON\n", os.Args[0])
+	_, _ = fmt.Fprintf(out, "       %s cache import <file>\tImport cache exported as JSON\n", os.Args[0])
+	flag.PrintDefaults()
+}
+
+// runCommand runs a command given as command line arguments and returns the exit code
+func runCommand(args []string) int {
+	if len(args) != 3 || args[0] != "cache" {
+		usage()
+		return 2
+	}
+
+	var err error
+	switch args[1] {
+	case "export":
+		var out *os.File
+		if out, err = os.Create(args[2]); err == nil {
+			err = core.ExportCache(core.CacheDir, out)
+			if closeErr := out.Close(); err == nil {
+				err = closeErr
+			}
+		}
+	case "import":
+		var in *os.File
+		if in, err = os.Open(args[2]); err == nil {
+			err = core.ImportCache(core.CacheDir, in)
+			_ = in.Close()
+		}
+	default:
+		usage()
+		return 2
+	}
+
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	return 0
+}
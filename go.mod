@@ -5,4 +5,6 @@ go 1.16
 require (
 	fyne.io/fyne/v2 v2.1.2
 	github.com/anaskhan96/soup v1.2.5
+	github.com/fsnotify/fsnotify v1.4.9
+	github.com/gorilla/websocket v1.4.2
 )
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/gob"
 	"errors"
+	"fmt"
 	"github.com/jaeha-choi/DFF/internal/datatype"
 	"os"
 	"strconv"
@@ -11,14 +12,15 @@ import (
 )
 
 // Version is used to keep track of cache file versions.
-// If cache structure is edited in any way, this value must be incremented.
-const Version uint16 = 2
+// If cache structure is edited in any way, this value must be incremented, and a migration from
+// the previous version must be added to migrations.
+const Version uint16 = 8
 
-// Capacity is the max allowed number of champions to hold
-const Capacity int = 16
+// DefaultCapacity is the max allowed number of champions to hold, unless set with SetCapacity
+const DefaultCapacity int = 16
 
-// Expiration data expiration time in days
-const Expiration = 7
+// DefaultExpiration is how long data is used, unless set for the mode with SetExpiration
+const DefaultExpiration = 7 * 24 * time.Hour
 
 var incompatibleCacheError = errors.New("existing cache is incompatible")
 
@@ -27,9 +29,33 @@ type Cache struct {
 	Capacity          int
 	Size              int
 	GameClientVersion string // Must be updated once game client API is accessible
-	Head              *Node
-	Tail              *Node
-	Existing          map[int]*Node
+	// Bracket identifies the region and tier of build data. Data of other brackets is not used.
+	// Bracket is not saved, as it is set from the configuration.
+	Bracket string
+	// Expiration is how long data of each build mode is used. Missing modes use DefaultExpiration.
+	// Expiration is not saved, as it is set from the configuration.
+	Expiration map[datatype.GameMode]time.Duration
+	// Stats are not saved, and count lookups since the cache was created or restored
+	Stats    Stats
+	Head     *Node
+	Tail     *Node
+	Existing map[int]*Node
+}
+
+// Stats are statistics of cache lookups
+type Stats struct {
+	Hits      int
+	Misses    int
+	Evictions int
+}
+
+// String implements the fmt.Stringer interface.
+func (s Stats) String() string {
+	hitRate := 0.0
+	if s.Hits+s.Misses > 0 {
+		hitRate = float64(s.Hits) / float64(s.Hits+s.Misses) * 100
+	}
+	return fmt.Sprintf("hits: %d, misses: %d (%.1f%% hit rate), evictions: %d", s.Hits, s.Misses, hitRate, s.Evictions)
 }
 
 type Node struct {
@@ -42,16 +68,25 @@ type NodeValue struct {
 	Key     int
 	URF     CachedData
 	ARAM    CachedData
+	Arena   CachedData
 	Default []CachedData
+	// Stale is true if data was retrieved for a previous patch or is missing fields of newer cache versions.
+	// Data of a stale champion is discarded when it is used.
+	Stale bool
 }
 
 type CachedData struct {
-	CreationTime time.Time
-	URL          string
-
-	Spells    datatype.Spells
-	RunePages []datatype.DFFRunePage
-	ItemPages datatype.ItemPage
+	CreationTime time.Time `json:"creation_time"`
+	URL          string    `json:"url"`
+	Bracket      string    `json:"bracket"`
+
+	Spells    datatype.Spells        `json:"spells"`
+	RunePages []datatype.DFFRunePage `json:"rune_pages"`
+	ItemPages datatype.ItemPage      `json:"item_pages"`
+	// ItemVariants holds statistics of each item set in ItemPages
+	ItemVariants []datatype.DFFItemVariant `json:"item_variants"`
+	SkillOrders  []datatype.DFFSkillOrder  `json:"skill_orders"`
+	Matchups     []datatype.DFFMatchup     `json:"matchups"`
 }
 
 // NewCache create new cache
@@ -64,17 +99,36 @@ func NewCache(gameVer string) *Cache {
 
 	return &Cache{
 		CacheVersion:      Version,
-		Capacity:          Capacity,
+		Capacity:          DefaultCapacity,
 		Size:              0,
 		GameClientVersion: gameVer,
+		Expiration:        make(map[datatype.GameMode]time.Duration),
 		Head:              head,
 		Tail:              tail,
-		Existing:          make(map[int]*Node, Capacity),
+		Existing:          make(map[int]*Node, DefaultCapacity),
 	}
 }
 
-// RestoreCache restore saved cache. If cache is incompatible, returns incompatibleCacheError
+// RestoreCache restore saved cache. If the game version changed, every champion is marked stale,
+// so that data is retrieved again when each champion is used. If cache is incompatible, returns incompatibleCacheError
 func RestoreCache(filename string, gameVer string) (cache *Cache, err error) {
+	if cache, err = ReadCache(filename); err != nil {
+		return nil, err
+	}
+
+	if cache.GameClientVersion != gameVer {
+		cache.markStale()
+		cache.GameClientVersion = gameVer
+	}
+
+	cache.evict()
+
+	return cache, nil
+}
+
+// ReadCache reads saved cache without checking the game version. Caches of older versions are migrated
+// to the current version. Returns incompatibleCacheError if the cache version cannot be migrated.
+func ReadCache(filename string) (cache *Cache, err error) {
 	file, err := os.Open(filename)
 	if err != nil {
 		return nil, err
@@ -93,21 +147,19 @@ func RestoreCache(filename string, gameVer string) (cache *Cache, err error) {
 		return nil, err
 	}
 
-	// If cache is incompatible, returns incompatibleCacheError
-	if cacheVerLocal != Version || gameVerLocal != gameVer {
+	if cacheVerLocal < oldestVersion || cacheVerLocal > Version {
 		return nil, incompatibleCacheError
 	}
 
-	err = decoder.Decode(&cache)
-	if cache != nil {
-		cache.CacheVersion = cacheVerLocal
-		cache.GameClientVersion = gameVerLocal
-		for len(cache.Existing) >= Capacity {
-			cache.delLast()
-		}
+	if err = decoder.Decode(&cache); err != nil {
+		return nil, err
+	}
+	cache.GameClientVersion = gameVerLocal
+	if err = cache.migrate(cacheVerLocal); err != nil {
+		return nil, err
 	}
 
-	return
+	return cache, nil
 }
 
 // SaveCache save cache
@@ -140,7 +192,8 @@ func (c *Cache) GetPutNode(id int) (node *Node, isCached bool) {
 				Key:     id,
 				URF:     CachedData{},
 				ARAM:    CachedData{},
-				Default: make([]CachedData, 5),
+				Arena:   CachedData{},
+				Default: make([]CachedData, len(PositionList)),
 			},
 		}
 		c.Existing[id] = node
@@ -159,45 +212,109 @@ func (c *Cache) GetPutNode(id int) (node *Node, isCached bool) {
 	c.Head.Next.Prev = node
 	c.Head.Next = node
 
+	// The new node is at the front, so it is not evicted
+	c.evict()
+
 	return
 }
 
+// SetCapacity sets the max allowed number of champions to hold, evicting least recently used champions
+// if the cache is full. capacity is at least 1.
+func (c *Cache) SetCapacity(capacity int) {
+	if capacity < 1 {
+		capacity = 1
+	}
+	c.Capacity = capacity
+	c.evict()
+}
+
+// SetExpiration sets how long data of the build mode of mode is used
+func (c *Cache) SetExpiration(mode datatype.GameMode, expiration time.Duration) {
+	if info := datatype.LookupMode(mode); info != nil {
+		c.Expiration[info.BuildMode] = expiration
+	}
+}
+
+// expiration returns how long data of mode is used
+func (c *Cache) expiration(mode datatype.GameMode) time.Duration {
+	if info := datatype.LookupMode(mode); info != nil {
+		if expiration, ok := c.Expiration[info.BuildMode]; ok {
+			return expiration
+		}
+	}
+	return DefaultExpiration
+}
+
+// GetPut returns cached data of the build mode. Modes without their own build data share the slot
+// of datatype.Default. Returns nil if mode is unknown.
 func (c *Cache) GetPut(id int, mode datatype.GameMode, position Position) (data *CachedData, isCached bool) {
 	var node *Node
 	node, isCached = c.GetPutNode(id)
-
-	if mode == datatype.Urf {
-		data = &node.Value.URF
-	} else if mode == datatype.Aram {
-		data = &node.Value.ARAM
-	} else if mode == datatype.Default {
-		data = &node.Value.Default[position]
+	if node.Value.Stale {
+		node.Value.reset()
 	}
 
+	data = node.Value.slot(mode, position)
+
 	if data != nil {
 		// If expiration date passed, remove data
-		if t := time.Now().Sub(data.CreationTime); t >= time.Hour*24*Expiration {
-			if mode == datatype.Urf {
-				node.Value.URF = CachedData{}
-				data = &node.Value.URF
-			} else if mode == datatype.Aram {
-				node.Value.ARAM = CachedData{}
-				data = &node.Value.ARAM
-			} else if mode == datatype.Default {
-				node.Value.Default[position] = CachedData{}
-				data = &node.Value.Default[position]
-			}
+		if t := time.Now().Sub(data.CreationTime); t >= c.expiration(mode) {
+			*data = CachedData{}
+			isCached = false
+		}
+		// Data of other brackets is replaced, so that builds of different brackets are not mixed
+		if data.Bracket != c.Bracket {
+			*data = CachedData{}
 			isCached = false
 		}
 		if data.RunePages == nil {
 			isCached = false
 		}
+		data.Bracket = c.Bracket
 	}
 	//fmt.Println("Using cached data: ", isCached)
 
+	if isCached {
+		c.Stats.Hits++
+	} else {
+		c.Stats.Misses++
+	}
+
 	return
 }
 
+// Invalidate discards cached data of id, so that it is retrieved again on the next GetPut
+func (c *Cache) Invalidate(id int, mode datatype.GameMode, position Position) {
+	if node, exist := c.Existing[id]; exist && node != nil {
+		if data := node.Value.slot(mode, position); data != nil {
+			*data = CachedData{}
+		}
+	}
+}
+
+// slot returns the cached data for the build data of mode
+func (value *NodeValue) slot(mode datatype.GameMode, position Position) *CachedData {
+	info := datatype.LookupMode(mode)
+	if info == nil {
+		return nil
+	}
+
+	switch info.BuildMode {
+	case datatype.Urf:
+		return &value.URF
+	case datatype.Aram:
+		return &value.ARAM
+	case datatype.Arena:
+		return &value.Arena
+	case datatype.Default:
+		if position < 0 || int(position) >= len(value.Default) {
+			return nil
+		}
+		return &value.Default[position]
+	}
+	return nil
+}
+
 // delLast deletes the last node in the cache (excluding head/tail)
 func (c *Cache) delLast() {
 	if len(c.Existing) > 0 {
@@ -208,6 +325,14 @@ func (c *Cache) delLast() {
 	}
 }
 
+// evict deletes least recently used nodes until the cache is within its capacity
+func (c *Cache) evict() {
+	for c.Size > c.Capacity && len(c.Existing) > 0 {
+		c.delLast()
+		c.Stats.Evictions++
+	}
+}
+
 // MarshalBinary implements the encoding.BinaryMarshaler interface.
 func (c Cache) MarshalBinary() (data []byte, err error) {
 	var buf bytes.Buffer
@@ -247,7 +372,8 @@ func (c *Cache) UnmarshalBinary(data []byte) (err error) {
 	c.Head = &Node{}
 	c.Tail = &Node{}
 
-	c.Existing = make(map[int]*Node, Capacity)
+	c.Existing = make(map[int]*Node, c.Capacity)
+	c.Expiration = make(map[datatype.GameMode]time.Duration)
 
 	curr := c.Head
 	prev := curr
@@ -3,8 +3,10 @@ package cache
 import (
 	"bytes"
 	"encoding/gob"
+	"encoding/json"
 	"github.com/jaeha-choi/DFF/internal/datatype"
 	"testing"
+	"testing/quick"
 	"time"
 )
 
@@ -80,3 +82,221 @@ func TestEncodeDecode(t *testing.T) {
 		t.Error("Incorrect result for TestCache")
 	}
 }
+
+func TestJSON(t *testing.T) {
+	c := NewCache("version")
+
+	creationTime := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	data, _ := c.GetPut(0, datatype.Default, Adc)
+	data.CreationTime = creationTime
+	data.URL = "someURL"
+	data.RunePages = []datatype.DFFRunePage{{Name: "page", WinRate: 51.5}}
+	data, _ = c.GetPut(1, datatype.Aram, None)
+	data.CreationTime = creationTime
+	c.GetPut(2, datatype.Urf, None)
+	c.GetPut(0, datatype.Default, Support)
+
+	b, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Cache
+	if err = json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.String() != "0\t2\t1\t" || decoded.GameClientVersion != "version" {
+		t.Error("Incorrect result for TestJSON: ", decoded.String())
+	}
+
+	adc := decoded.Existing[0].Value.Default[Adc]
+	if !adc.CreationTime.Equal(creationTime) || adc.URL != "someURL" || adc.RunePages[0].WinRate != 51.5 {
+		t.Error("Incorrect result for TestJSON")
+	}
+
+	if !decoded.Existing[1].Value.ARAM.CreationTime.Equal(creationTime) {
+		t.Error("Incorrect result for TestJSON")
+	}
+
+	if err = json.Unmarshal([]byte(`{"cache_version": 8, "entries": [{"champion_id": 1, "default": {"Bot": {}}}]}`),
+		&decoded); err != invalidPositionError {
+		t.Error("Invalid position should return an error: ", err)
+	}
+
+	if err = json.Unmarshal([]byte(`{"cache_version": 99, "entries": []}`), &decoded); err != incompatibleCacheError {
+		t.Error("Unknown cache version should return an error: ", err)
+	}
+
+	// Older caches are migrated, and entries beyond the capacity are kept
+	old := `{"cache_version": 3, "capacity": 1, "entries": [{"champion_id": 1}, {"champion_id": 2}]}`
+	if err = json.Unmarshal([]byte(old), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.CacheVersion != Version || decoded.String() != "1\t2\t" || decoded.Stats.Evictions != 0 ||
+		!decoded.Existing[1].Value.Stale {
+		t.Error("Incorrect migrated cache: ", decoded.CacheVersion, decoded.String(), decoded.Stats.Evictions)
+	}
+}
+
+func TestGetPutModes(t *testing.T) {
+	c := NewCache("version")
+
+	data, _ := c.GetPut(0, datatype.Default, Mid)
+	data.URL = "default"
+
+	// Modes without their own build data share the slot of the default mode
+	if shared, _ := c.GetPut(0, datatype.OneForAll, Mid); shared != data {
+		t.Error("One for All should use the default slot")
+	}
+
+	arena, _ := c.GetPut(0, datatype.Arena, None)
+	if arena == nil || arena == data || arena.URL != "" {
+		t.Error("Arena should have its own slot")
+	}
+
+	if unknown, _ := c.GetPut(0, datatype.GameMode(-1), None); unknown != nil {
+		t.Error("Unknown mode should not have a slot")
+	}
+}
+
+func TestGetPutBracket(t *testing.T) {
+	c := NewCache("version")
+	c.Bracket = "kr/diamond_plus"
+
+	data, _ := c.GetPut(103, datatype.Default, Mid)
+	data.CreationTime = time.Now()
+	data.RunePages = []datatype.DFFRunePage{{Name: "kr"}}
+	if _, isCached := c.GetPut(103, datatype.Default, Mid); !isCached {
+		t.Error("Data of the same bracket should be cached")
+	}
+
+	c.Bracket = "na/gold_plus"
+	if data, isCached := c.GetPut(103, datatype.Default, Mid); isCached || data.RunePages != nil || data.Bracket != c.Bracket {
+		t.Error("Data of another bracket should not be used: ", data)
+	}
+}
+
+func TestEviction(t *testing.T) {
+	c := NewCache("version")
+	c.SetCapacity(2)
+
+	c.GetPut(0, datatype.Default, Mid)
+	c.GetPut(1, datatype.Default, Mid)
+	c.GetPut(0, datatype.Aram, None)
+	c.GetPut(2, datatype.Default, Mid)
+
+	// 1 is the least recently used champion
+	if c.String() != "2\t0\t" || c.Size != 2 || c.Existing[1] != nil {
+		t.Error("Incorrect champions after eviction: ", c.String())
+	}
+
+	c.SetCapacity(1)
+	if c.String() != "2\t" || c.Stats.Evictions != 2 {
+		t.Error("Incorrect champions after reducing capacity: ", c.String(), c.Stats)
+	}
+}
+
+func TestExpiration(t *testing.T) {
+	c := NewCache("version")
+	c.SetExpiration(datatype.Aram, time.Hour)
+
+	for _, mode := range []datatype.GameMode{datatype.Default, datatype.Aram} {
+		data, _ := c.GetPut(0, mode, Mid)
+		data.CreationTime = time.Now().Add(-2 * time.Hour)
+		data.RunePages = []datatype.DFFRunePage{{Name: "page"}}
+	}
+
+	if _, isCached := c.GetPut(0, datatype.Default, Mid); !isCached {
+		t.Error("Data should be used until the default expiration")
+	}
+	if _, isCached := c.GetPut(0, datatype.Aram, Mid); isCached {
+		t.Error("Data should expire after the expiration of the mode")
+	}
+	// Stats include the lookups setting data
+	if c.Stats.Hits != 1 || c.Stats.Misses != 3 {
+		t.Error("Incorrect stats: ", c.Stats)
+	}
+}
+
+// TestLRUConsistency checks that the linked list and the map agree with a simple LRU model
+// after arbitrary sequences of GetPut
+func TestLRUConsistency(t *testing.T) {
+	modes := []datatype.GameMode{datatype.Default, datatype.Aram, datatype.Urf, datatype.Arena, datatype.OneForAll}
+
+	property := func(ops []uint16, capacity uint8) bool {
+		c := NewCache("version")
+		c.SetCapacity(int(capacity%8) + 1)
+
+		// Keys from the most recently used
+		var model []int
+		for _, op := range ops {
+			id := int(op % 24)
+			c.GetPut(id, modes[int(op/24)%len(modes)], Position(int(op/120)%5))
+
+			for i, key := range model {
+				if key == id {
+					model = append(model[:i], model[i+1:]...)
+					break
+				}
+			}
+			model = append([]int{id}, model...)
+			if len(model) > c.Capacity {
+				model = model[:c.Capacity]
+			}
+
+			if !consistent(c, model) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if err := quick.Check(property, &quick.Config{MaxCount: 500}); err != nil {
+		t.Error(err)
+	}
+}
+
+// consistent returns true if the linked list of c holds keys in order, and every node is in the map
+func consistent(c *Cache, keys []int) bool {
+	if c.Size != len(keys) || len(c.Existing) != len(keys) || c.Size > c.Capacity {
+		return false
+	}
+
+	prev := c.Head
+	curr := c.Head.Next
+	for _, key := range keys {
+		if curr == c.Tail || curr.Prev != prev || curr.Value.Key != key || c.Existing[key] != curr {
+			return false
+		}
+		prev = curr
+		curr = curr.Next
+	}
+	return curr == c.Tail && c.Tail.Prev == prev
+}
+
+func TestParseAssignedPosition(t *testing.T) {
+	for name, expected := range map[string]Position{"top": Top, "jungle": Jungle, "middle": Mid, "bottom": Adc, "UTILITY": Support} {
+		if position, ok := ParseAssignedPosition(name); !ok || position != expected {
+			t.Error("Incorrect position of ", name, ": ", position)
+		}
+	}
+	if position, ok := ParseAssignedPosition(""); ok || position != None {
+		t.Error("Empty position should not be assigned: ", position)
+	}
+}
+
+func TestPositionJSON(t *testing.T) {
+	for input, expected := range map[string]Position{`"Mid"`: Mid, `""`: None, `4`: Support, `-1`: None} {
+		var position Position
+		if err := json.Unmarshal([]byte(input), &position); err != nil || position != expected {
+			t.Error("Incorrect position of ", input, ": ", position, err)
+		}
+	}
+	for _, input := range []string{`"Bot"`, `5`, `-2`} {
+		var position Position
+		if err := json.Unmarshal([]byte(input), &position); err != invalidPositionError {
+			t.Error("Invalid position ", input, " should return an error: ", err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"encoding/json"
+)
+
+// jsonCache is a human-readable representation of Cache.
+// Entries are ordered from the most recently used to the least recently used.
+type jsonCache struct {
+	CacheVersion      uint16      `json:"cache_version"`
+	GameClientVersion string      `json:"game_client_version"`
+	Capacity          int         `json:"capacity"`
+	Entries           []jsonEntry `json:"entries"`
+}
+
+// jsonEntry is a human-readable representation of NodeValue. Empty data are omitted.
+type jsonEntry struct {
+	ChampionID int                   `json:"champion_id"`
+	URF        *CachedData           `json:"urf,omitempty"`
+	ARAM       *CachedData           `json:"aram,omitempty"`
+	Arena      *CachedData           `json:"arena,omitempty"`
+	Default    map[string]CachedData `json:"default,omitempty"` // keyed by Position.String()
+	Stale      bool                  `json:"stale,omitempty"`
+}
+
+// isEmpty returns true if data was never set
+func (data *CachedData) isEmpty() bool {
+	return data.CreationTime.IsZero() && data.URL == "" && data.RunePages == nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (c Cache) MarshalJSON() ([]byte, error) {
+	out := jsonCache{
+		CacheVersion:      c.CacheVersion,
+		GameClientVersion: c.GameClientVersion,
+		Capacity:          c.Capacity,
+		Entries:           make([]jsonEntry, 0, c.Size),
+	}
+
+	curr := c.Head.Next
+	for i := 0; i < c.Size; i++ {
+		value := curr.Value
+		entry := jsonEntry{ChampionID: value.Key, Stale: value.Stale}
+		if !value.URF.isEmpty() {
+			entry.URF = &value.URF
+		}
+		if !value.ARAM.isEmpty() {
+			entry.ARAM = &value.ARAM
+		}
+		if !value.Arena.isEmpty() {
+			entry.Arena = &value.Arena
+		}
+		for pos, data := range value.Default {
+			if !data.isEmpty() {
+				if entry.Default == nil {
+					entry.Default = make(map[string]CachedData)
+				}
+				entry.Default[Position(pos).String()] = data
+			}
+		}
+		out.Entries = append(out.Entries, entry)
+		curr = curr.Next
+	}
+
+	return json.Marshal(&out)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Caches of older versions are migrated to the current
+// version, and incompatibleCacheError is returned if the cache version cannot be migrated.
+// The capacity grows to hold every entry, so that no entry is evicted while importing.
+func (c *Cache) UnmarshalJSON(data []byte) (err error) {
+	var in jsonCache
+	if err = json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	if in.CacheVersion < oldestVersion || in.CacheVersion > Version {
+		return incompatibleCacheError
+	}
+
+	*c = *NewCache(in.GameClientVersion)
+	if in.Capacity > 0 {
+		c.Capacity = in.Capacity
+	}
+	if len(in.Entries) > c.Capacity {
+		c.Capacity = len(in.Entries)
+	}
+
+	// Insert from the least recently used entry to keep the order
+	for i := len(in.Entries) - 1; i >= 0; i-- {
+		entry := in.Entries[i]
+		node, _ := c.GetPutNode(entry.ChampionID)
+		node.Value.Stale = entry.Stale
+		if entry.URF != nil {
+			node.Value.URF = *entry.URF
+		}
+		if entry.ARAM != nil {
+			node.Value.ARAM = *entry.ARAM
+		}
+		if entry.Arena != nil {
+			node.Value.Arena = *entry.Arena
+		}
+		for name, cachedData := range entry.Default {
+			pos, ok := ParsePosition(name)
+			if !ok || pos == None {
+				return invalidPositionError
+			}
+			node.Value.Default[pos] = cachedData
+		}
+	}
+
+	return c.migrate(in.CacheVersion)
+}
@@ -0,0 +1,53 @@
+package cache
+
+// oldestVersion is the oldest cache version which can be migrated to Version
+const oldestVersion uint16 = 2
+
+// migrations upgrade cache decoded from an older version step by step. migrations[v] upgrades cache of
+// version v to v+1. Every version from oldestVersion to Version-1 must have a migration.
+// Fields are only added between versions, so older caches are decoded into the current structure
+// with new fields left empty. Champions whose data lack fields needed by DFF are marked stale.
+var migrations = map[uint16]func(c *Cache){
+	// Arena slot added
+	2: func(c *Cache) {},
+	// Skill orders added
+	3: (*Cache).markStale,
+	// Item sets of each core build and their statistics added
+	4: (*Cache).markStale,
+	// Matchups added
+	5: (*Cache).markStale,
+	// Bracket added. Data without a bracket is replaced by GetPut.
+	6: func(c *Cache) {},
+	// Stale added
+	7: func(c *Cache) {},
+}
+
+// migrate upgrades cache of version to Version. Returns incompatibleCacheError if version cannot be migrated.
+func (c *Cache) migrate(version uint16) error {
+	if version < oldestVersion || version > Version {
+		return incompatibleCacheError
+	}
+
+	for ; version < Version; version++ {
+		migrations[version](c)
+	}
+	c.CacheVersion = Version
+
+	return nil
+}
+
+// markStale marks every champion stale, so that its data is retrieved again when it is used
+func (c *Cache) markStale() {
+	for _, node := range c.Existing {
+		node.Value.Stale = true
+	}
+}
+
+// reset discards data of every mode
+func (value *NodeValue) reset() {
+	value.URF = CachedData{}
+	value.ARAM = CachedData{}
+	value.Arena = CachedData{}
+	value.Default = make([]CachedData, len(PositionList))
+	value.Stale = false
+}
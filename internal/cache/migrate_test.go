@@ -0,0 +1,142 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// v5CachedData is CachedData of cache version 5, before matchups and brackets were added
+type v5CachedData struct {
+	CreationTime time.Time
+	URL          string
+	RunePages    []datatype.DFFRunePage
+}
+
+// v5NodeValue is NodeValue of cache version 5
+type v5NodeValue struct {
+	Key     int
+	URF     v5CachedData
+	ARAM    v5CachedData
+	Arena   v5CachedData
+	Default []v5CachedData
+}
+
+// v5Cache is encoded like Cache of cache version 5
+type v5Cache struct {
+	values []v5NodeValue
+}
+
+func (c v5Cache) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := gob.NewEncoder(&buf)
+	capacity, size := DefaultCapacity, len(c.values)
+	if err := encoder.Encode(&capacity); err != nil {
+		return nil, err
+	}
+	if err := encoder.Encode(&size); err != nil {
+		return nil, err
+	}
+	for i := range c.values {
+		if err := encoder.Encode(&c.values[i]); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// writeCache writes c with the header of version, like SaveCache
+func writeCache(t *testing.T, filename string, version uint16, gameVer string, c interface{}) {
+	var buf bytes.Buffer
+	encoder := gob.NewEncoder(&buf)
+	if err := encoder.Encode(&version); err != nil {
+		t.Fatal(err)
+	}
+	if err := encoder.Encode(&gameVer); err != nil {
+		t.Fatal(err)
+	}
+	if err := encoder.Encode(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	for version := oldestVersion; version < Version; version++ {
+		if migrations[version] == nil {
+			t.Error("Missing migration from version ", version)
+		}
+	}
+}
+
+func TestMigrateOldVersion(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cache.bin")
+	old := v5Cache{values: []v5NodeValue{
+		{Key: 103, Default: []v5CachedData{{}, {}, {CreationTime: time.Now(), URL: "mid", RunePages: []datatype.DFFRunePage{{Name: "page"}}}, {}, {}}},
+		{Key: 99, Default: make([]v5CachedData, 5)},
+	}}
+	writeCache(t, filename, 5, "12.1.1", &old)
+
+	c, err := RestoreCache(filename, "12.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.CacheVersion != Version || c.String() != "103\t99\t" || c.Existing[103].Value.Default[Mid].URL != "mid" {
+		t.Fatal("Incorrect migrated cache: ", c.String())
+	}
+
+	// Version 5 has no matchups, so data is retrieved again
+	if !c.Existing[103].Value.Stale {
+		t.Error("Champions of version 5 should be stale")
+	}
+	if data, isCached := c.GetPut(103, datatype.Default, Mid); isCached || data.URL != "" {
+		t.Error("Stale data should not be used: ", data.URL)
+	}
+
+	writeCache(t, filename, Version+1, "12.1.1", &old)
+	if _, err = RestoreCache(filename, "12.1.1"); err != incompatibleCacheError {
+		t.Error("Newer cache versions should be incompatible: ", err)
+	}
+}
+
+func TestRestoreCachePatch(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cache.bin")
+	c := NewCache("12.1.1")
+	for _, id := range []int{99, 103} {
+		data, _ := c.GetPut(id, datatype.Default, Mid)
+		data.CreationTime = time.Now()
+		data.RunePages = []datatype.DFFRunePage{{Name: "page"}}
+	}
+	if err := c.SaveCache(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := RestoreCache(filename, "12.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, isCached := restored.GetPut(103, datatype.Default, Mid); !isCached {
+		t.Error("Data of the same patch should be used")
+	}
+
+	// Champions are kept after a patch, and only the used champion is reset
+	restored, err = RestoreCache(filename, "12.2.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.GameClientVersion != "12.2.1" || restored.Size != 2 {
+		t.Fatal("Champions should be kept after a patch: ", restored.String())
+	}
+	if _, isCached := restored.GetPut(103, datatype.Default, Mid); isCached || restored.Existing[103].Value.Stale {
+		t.Error("Data of the previous patch should be retrieved again")
+	}
+	if !restored.Existing[99].Value.Stale || restored.Existing[99].Value.Default[Mid].RunePages == nil {
+		t.Error("Unused champions should be stale until they are used")
+	}
+}
@@ -1,5 +1,13 @@
 package cache
 
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var invalidPositionError = errors.New("invalid position")
+
 type Position int
 
 const (
@@ -29,3 +37,60 @@ func (p Position) String() string {
 		return ""
 	}
 }
+
+// ParsePosition returns the Position whose String is s, or false if s is not a valid position
+func ParsePosition(s string) (Position, bool) {
+	for _, p := range PositionList {
+		if p.String() == s {
+			return p, true
+		}
+	}
+	return None, s == ""
+}
+
+// MarshalJSON implements the json.Marshaler interface. Position is encoded as its name.
+func (p Position) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Both names and numbers are accepted.
+func (p *Position) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err != nil {
+		var i int
+		if err = json.Unmarshal(b, &i); err != nil {
+			return err
+		}
+		if i < int(None) || i >= len(PositionList) {
+			return invalidPositionError
+		}
+		*p = Position(i)
+		return nil
+	}
+
+	pos, ok := ParsePosition(name)
+	if !ok {
+		return invalidPositionError
+	}
+	*p = pos
+	return nil
+}
+
+// ParseAssignedPosition returns the Position of assignedPosition in a champion select session
+// ("top", "jungle", "middle", "bottom", "utility"), or false if no position is assigned (e.g. blind pick)
+func ParseAssignedPosition(assignedPosition string) (Position, bool) {
+	switch strings.ToLower(assignedPosition) {
+	case "top":
+		return Top, true
+	case "jungle":
+		return Jungle, true
+	case "middle":
+		return Mid, true
+	case "bottom":
+		return Adc, true
+	case "utility":
+		return Support, true
+	default:
+		return None, false
+	}
+}
@@ -0,0 +1,320 @@
+// Package control provides a local HTTP/JSON API to inspect and control DFF
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/core"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/pkg/log"
+	"mime"
+	"net"
+	"net/http"
+	"sync"
+)
+
+var notLoopbackError = errors.New("control API must listen on a loopback address")
+
+// Controller is the part of core.DFFClient controlled by Server
+type Controller interface {
+	CurrentData() (*cache.CachedData, bool)
+	Features() core.Features
+	SetFeatures(features core.Features)
+	RefreshCurrent() error
+}
+
+// options are choices offered by DFF, such as rune pages
+type options struct {
+	Options  []string `json:"options"`
+	Selected int      `json:"selected"`
+
+	next     func(options []string, selected int, onSelect func(idx int))
+	onSelect func(int)
+}
+
+// Status is the response of GET /status
+type Status struct {
+	Status    string        `json:"status"`
+	Champion  string        `json:"champion"`
+	Roles     *options      `json:"roles"`
+	RunePages *options      `json:"rune_pages"`
+	ItemSets  *options      `json:"item_sets"`
+	Features  core.Features `json:"features"`
+}
+
+// Server implements core.Observer by recording updates, which are served over HTTP.
+// Every update is passed to the next Observer, so that the API can be used along with the window.
+type Server struct {
+	next       core.Observer
+	controller Controller
+	log        *log.Logger
+	port       string // port of Host accepted by the handler, any port if empty
+
+	mu        sync.Mutex
+	status    string
+	champion  string
+	roles     options
+	runePages options
+	itemSets  options
+}
+
+// NewServer creates a Server which passes updates to next
+func NewServer(controller Controller, next core.Observer, logger *log.Logger) *Server {
+	s := &Server{
+		next:       next,
+		controller: controller,
+		log:        logger,
+	}
+	s.roles.next = next.SetRoles
+	s.runePages.next = next.SetRunePages
+	s.itemSets.next = next.SetItemSets
+	return s
+}
+
+// ListenAndServe serves the API on addr, which must be a loopback address (e.g. "127.0.0.1:7723").
+// It blocks until the server fails.
+func (s *Server) ListenAndServe(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return notLoopbackError
+	}
+
+	_, s.port, _ = net.SplitHostPort(addr)
+	s.log.Info("Control API listening on ", addr)
+	return http.ListenAndServe(addr, s.Handler())
+}
+
+// Handler returns the HTTP handler of the API:
+//
+//	GET  /status     status, champion, available choices and features
+//	GET  /data       build data of the current champion
+//	POST /role       picks a role, {"index": n}
+//	POST /rune       picks a rune page, {"index": n}
+//	POST /item       picks an item set, {"index": n}
+//	PUT  /features   sets features, {"enable_rune": true, "enable_item": true, "enable_spell": true}
+//	POST /refresh    discards cached data of the current champion and retrieves it again
+//
+// Requests from web pages are rejected, so that websites opened in a browser cannot control DFF.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", s.method("GET", s.handleStatus))
+	mux.HandleFunc("/data", s.method("GET", s.handleData))
+	mux.HandleFunc("/role", s.method("POST", s.handleSelect(&s.roles)))
+	mux.HandleFunc("/rune", s.method("POST", s.handleSelect(&s.runePages)))
+	mux.HandleFunc("/item", s.method("POST", s.handleSelect(&s.itemSets)))
+	mux.HandleFunc("/features", s.method("PUT", s.handleFeatures))
+	mux.HandleFunc("/refresh", s.method("POST", s.handleRefresh))
+	return s.local(mux)
+}
+
+// local returns a handler which only accepts requests from local tools.
+// Host is checked against DNS rebinding, and Origin and Content-Type against requests sent by browsers.
+func (s *Server) local(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.isLocalHost(r.Host) {
+			writeError(w, http.StatusForbidden, "invalid host")
+			return
+		}
+		if r.Header.Get("Origin") != "" {
+			writeError(w, http.StatusForbidden, "requests from web pages are not allowed")
+			return
+		}
+		if r.Method == "POST" || r.Method == "PUT" {
+			// Browsers cannot send application/json to other origins without a preflight request
+			if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, "content type must be application/json")
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// isLocalHost returns true if host is a loopback address or localhost with the port of the server
+func (s *Server) isLocalHost(host string) bool {
+	name, port, err := net.SplitHostPort(host)
+	if err != nil {
+		return false
+	}
+	if s.port != "" && port != s.port {
+		return false
+	}
+	if ip := net.ParseIP(name); name != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return false
+	}
+	return true
+}
+
+// method returns a handler which only accepts requests with method m
+func (s *Server) method(m string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != m {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	status := Status{
+		Status:    s.status,
+		Champion:  s.champion,
+		Roles:     copyOptions(&s.roles),
+		RunePages: copyOptions(&s.runePages),
+		ItemSets:  copyOptions(&s.itemSets),
+		Features:  s.controller.Features(),
+	}
+	s.mu.Unlock()
+
+	writeJson(w, http.StatusOK, &status)
+}
+
+func (s *Server) handleData(w http.ResponseWriter, _ *http.Request) {
+	data, ok := s.controller.CurrentData()
+	if !ok {
+		writeError(w, http.StatusNotFound, "no champion is selected")
+		return
+	}
+	writeJson(w, http.StatusOK, data)
+}
+
+// handleSelect returns a handler which picks one of opts
+func (s *Server) handleSelect(opts *options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Index int `json:"index"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		s.mu.Lock()
+		if req.Index < 0 || req.Index >= len(opts.Options) || opts.onSelect == nil {
+			s.mu.Unlock()
+			writeError(w, http.StatusBadRequest, "invalid index")
+			return
+		}
+		opts.Selected = req.Index
+		values, callback, wrapped := opts.Options, opts.onSelect, opts.wrap(s)
+		s.mu.Unlock()
+
+		// Update the next Observer, as the choice was not made there
+		opts.next(values, req.Index, wrapped)
+		callback(req.Index)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) handleFeatures(w http.ResponseWriter, r *http.Request) {
+	features := s.controller.Features()
+	// Omitted fields are not changed
+	if err := json.NewDecoder(r.Body).Decode(&features); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.controller.SetFeatures(features)
+	writeJson(w, http.StatusOK, s.controller.Features())
+}
+
+func (s *Server) handleRefresh(w http.ResponseWriter, _ *http.Request) {
+	if err := s.controller.RefreshCurrent(); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// SetStatus implements core.Observer
+func (s *Server) SetStatus(status string) {
+	s.mu.Lock()
+	s.status = status
+	s.mu.Unlock()
+	s.next.SetStatus(status)
+}
+
+// SetChampion implements core.Observer
+func (s *Server) SetChampion(name string) {
+	s.mu.Lock()
+	s.champion = name
+	s.mu.Unlock()
+	s.next.SetChampion(name)
+}
+
+// SetRoles implements core.Observer
+func (s *Server) SetRoles(roles []string, selected int, onSelect func(idx int)) {
+	s.setOptions(&s.roles, roles, selected, onSelect)
+}
+
+// SetRunePages implements core.Observer
+func (s *Server) SetRunePages(pages []string, selected int, onSelect func(idx int)) {
+	s.setOptions(&s.runePages, pages, selected, onSelect)
+}
+
+// SetItemSets implements core.Observer
+func (s *Server) SetItemSets(itemSets []string, selected int, onSelect func(idx int)) {
+	s.setOptions(&s.itemSets, itemSets, selected, onSelect)
+}
+
+// SetSkillOrders implements core.Observer
+func (s *Server) SetSkillOrders(orders []datatype.DFFSkillOrder) {
+	s.next.SetSkillOrders(orders)
+}
+
+// RequestAttention implements core.Observer
+func (s *Server) RequestAttention() {
+	s.next.RequestAttention()
+}
+
+// setOptions records opts and passes them to the next Observer
+func (s *Server) setOptions(opts *options, values []string, selected int, onSelect func(idx int)) {
+	s.mu.Lock()
+	opts.Options = values
+	opts.Selected = selected
+	opts.onSelect = onSelect
+	wrapped := opts.wrap(s)
+	s.mu.Unlock()
+
+	opts.next(values, selected, wrapped)
+}
+
+// wrap returns onSelect which records choices made on the next Observer. s.mu must be held.
+func (opts *options) wrap(s *Server) func(idx int) {
+	onSelect := opts.onSelect
+	if onSelect == nil {
+		return nil
+	}
+	return func(idx int) {
+		s.mu.Lock()
+		opts.Selected = idx
+		s.mu.Unlock()
+		onSelect(idx)
+	}
+}
+
+// copyOptions returns a copy of opts, or nil if there is no option. s.mu must be held.
+func copyOptions(opts *options) *options {
+	if len(opts.Options) == 0 {
+		return nil
+	}
+	return &options{
+		Options:  append([]string{}, opts.Options...),
+		Selected: opts.Selected,
+	}
+}
+
+func writeJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJson(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,199 @@
+package control
+
+import (
+	"encoding/json"
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/core"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/pkg/log"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeController records calls from Server
+type fakeController struct {
+	mu        sync.Mutex
+	data      *cache.CachedData
+	features  core.Features
+	refreshed int
+}
+
+func (c *fakeController) CurrentData() (*cache.CachedData, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.data, c.data != nil
+}
+
+func (c *fakeController) Features() core.Features {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.features
+}
+
+func (c *fakeController) SetFeatures(features core.Features) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.features = features
+}
+
+func (c *fakeController) RefreshCurrent() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshed++
+	return nil
+}
+
+// nextObserver records rune pages passed by Server
+type nextObserver struct {
+	mu           sync.Mutex
+	status       string
+	runeSelected int
+}
+
+func (o *nextObserver) SetStatus(status string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.status = status
+}
+
+func (o *nextObserver) SetRunePages(pages []string, selected int, onSelect func(idx int)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.runeSelected = selected
+}
+
+func (o *nextObserver) SetChampion(string) {}
+
+func (o *nextObserver) SetRoles([]string, int, func(int)) {}
+
+func (o *nextObserver) SetItemSets([]string, int, func(int)) {}
+
+func (o *nextObserver) SetSkillOrders([]datatype.DFFSkillOrder) {}
+
+func (o *nextObserver) RequestAttention() {}
+
+// do sends a request to the handler and decodes the response to out if out is not nil
+func do(t *testing.T, handler http.Handler, method string, path string, body string, out interface{}) int {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Host = "127.0.0.1:7723"
+	if method == "POST" || method == "PUT" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if out != nil {
+		if err := json.NewDecoder(rec.Body).Decode(out); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rec.Code
+}
+
+func TestServer(t *testing.T) {
+	controller := &fakeController{features: core.Features{EnableRune: true, EnableItem: true, EnableSpell: true}}
+	next := &nextObserver{}
+	server := NewServer(controller, next, log.NewLogger(ioutil.Discard, log.DEBUG, ""))
+	handler := server.Handler()
+
+	if code := do(t, handler, "GET", "/data", "", nil); code != http.StatusNotFound {
+		t.Error("Incorrect status code without a champion: ", code)
+	}
+
+	selected := -1
+	server.SetStatus("Updated...")
+	server.SetChampion("Ahri")
+	server.SetRunePages([]string{"1. page", "2. page"}, 0, func(i int) { selected = i })
+	controller.data = &cache.CachedData{URL: "someURL"}
+
+	if next.status != "Updated..." {
+		t.Error("Status is not passed to the next observer")
+	}
+
+	var status Status
+	if code := do(t, handler, "GET", "/status", "", &status); code != http.StatusOK || status.Champion != "Ahri" ||
+		status.RunePages == nil || len(status.RunePages.Options) != 2 || status.Roles != nil || !status.Features.EnableItem {
+		t.Error("Incorrect status: ", code, status)
+	}
+
+	var data cache.CachedData
+	if code := do(t, handler, "GET", "/data", "", &data); code != http.StatusOK || data.URL != "someURL" {
+		t.Error("Incorrect data: ", code, data)
+	}
+
+	if code := do(t, handler, "POST", "/rune", `{"index": 1}`, nil); code != http.StatusNoContent || selected != 1 ||
+		next.runeSelected != 1 {
+		t.Error("Rune page is not selected: ", code, selected)
+	}
+	if code := do(t, handler, "POST", "/rune", `{"index": 2}`, nil); code != http.StatusBadRequest {
+		t.Error("Invalid index should be rejected: ", code)
+	}
+	if code := do(t, handler, "POST", "/role", `{"index": 0}`, nil); code != http.StatusBadRequest {
+		t.Error("Role without options should be rejected: ", code)
+	}
+
+	var features core.Features
+	if code := do(t, handler, "PUT", "/features", `{"enable_item": false}`, &features); code != http.StatusOK ||
+		!features.EnableRune || features.EnableItem || !features.EnableSpell {
+		t.Error("Incorrect features: ", code, features)
+	}
+
+	if code := do(t, handler, "POST", "/refresh", "", nil); code != http.StatusAccepted || controller.refreshed != 1 {
+		t.Error("Cache is not refreshed: ", code)
+	}
+
+	if code := do(t, handler, "GET", "/refresh", "", nil); code != http.StatusMethodNotAllowed {
+		t.Error("Incorrect status code for a wrong method: ", code)
+	}
+
+	if err := server.ListenAndServe("0.0.0.0:0"); err != notLoopbackError {
+		t.Error("Non-loopback address should be rejected: ", err)
+	}
+}
+
+func TestServerRejectsWebPages(t *testing.T) {
+	controller := &fakeController{}
+	server := NewServer(controller, &nextObserver{}, log.NewLogger(ioutil.Discard, log.DEBUG, ""))
+	server.port = "7723"
+	handler := server.Handler()
+
+	tests := []struct {
+		name        string
+		method      string
+		host        string
+		origin      string
+		contentType string
+		code        int
+	}{
+		{name: "loopback", method: "POST", host: "127.0.0.1:7723", contentType: "application/json", code: http.StatusAccepted},
+		{name: "localhost", method: "POST", host: "localhost:7723", contentType: "application/json; charset=utf-8", code: http.StatusAccepted},
+		{name: "rebound host", method: "POST", host: "attacker.example:7723", contentType: "application/json", code: http.StatusForbidden},
+		{name: "other port", method: "POST", host: "127.0.0.1:80", contentType: "application/json", code: http.StatusForbidden},
+		{name: "origin", method: "POST", host: "127.0.0.1:7723", origin: "https://attacker.example", contentType: "application/json", code: http.StatusForbidden},
+		{name: "get with origin", method: "GET", host: "127.0.0.1:7723", origin: "null", code: http.StatusForbidden},
+		{name: "text body", method: "POST", host: "127.0.0.1:7723", contentType: "text/plain", code: http.StatusUnsupportedMediaType},
+		{name: "no content type", method: "POST", host: "127.0.0.1:7723", code: http.StatusUnsupportedMediaType},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/refresh", strings.NewReader(""))
+		req.Host = test.host
+		if test.origin != "" {
+			req.Header.Set("Origin", test.origin)
+		}
+		if test.contentType != "" {
+			req.Header.Set("Content-Type", test.contentType)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != test.code {
+			t.Error("Incorrect status code for ", test.name, ": ", rec.Code)
+		}
+	}
+	if controller.refreshed != 2 {
+		t.Error("Rejected requests should not refresh: ", controller.refreshed)
+	}
+}
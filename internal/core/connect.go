@@ -0,0 +1,174 @@
+package core
+
+import (
+	"errors"
+	"github.com/jaeha-choi/DFF/internal/lcu"
+	"path/filepath"
+	"time"
+)
+
+// lockfileFallbackInterval is how often DFF looks for the League client while lockfiles are watched.
+// The client may be found in the process table without a lockfile in watched directories.
+const lockfileFallbackInterval = 10 * time.Second
+
+// accountAttempts is the number of requests for the account before credentials are read again
+const accountAttempts = 5
+
+// maxBackoff is the longest delay between attempts to connect
+const maxBackoff = 30 * time.Second
+
+var connectionLostError = errors.New("connection to the League client lost")
+
+var staleLockfileError = errors.New("lockfile of a closed League client")
+
+// backoff returns the delay before the next attempt after attempt failed attempts, doubling from 500ms
+func backoff(attempt int) time.Duration {
+	delay := 500 * time.Millisecond
+	for i := 0; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		return maxBackoff
+	}
+	return delay
+}
+
+// lockfileDirs returns directories where the lockfile may be found, starting with client_dir
+func (client *DFFClient) lockfileDirs() []string {
+	dirs := []string{filepath.Clean(client.ClientDir)}
+	for _, dir := range lcu.InstallDirs() {
+		if filepath.Clean(dir) != dirs[0] {
+			dirs = append(dirs, filepath.Clean(dir))
+		}
+	}
+	return dirs
+}
+
+// readLockfile reads the lockfile in dir. staleLockfileError is returned if its League client is not running.
+func readLockfile(dir string) (*lcu.Credentials, error) {
+	credentials, err := lcu.ReadLockfile(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !credentials.Listening() {
+		return nil, staleLockfileError
+	}
+	return credentials, nil
+}
+
+// findClient returns credentials of the running League client, and the directory of the lockfile they were read
+// from. The lockfile in client_dir is used first, then the process table and lockfiles in known install
+// directories. lockfileDir is empty if the League client was found in the process table.
+func (client *DFFClient) findClient() (credentials *lcu.Credentials, lockfileDir string, err error) {
+	dirs := client.lockfileDirs()
+	if credentials, err = readLockfile(dirs[0]); err == nil {
+		return credentials, dirs[0], nil
+	}
+	client.Log.Debug(err)
+
+	if credentials, err = lcu.FindClientProcess(); err == nil {
+		client.Log.Debug("League client found in the process table")
+		return credentials, "", nil
+	}
+	client.Log.Debug(err)
+
+	for _, dir := range dirs[1:] {
+		if credentials, err = readLockfile(dir); err == nil {
+			client.Log.Info("League client found in ", dir, ". Set client_dir to skip searching")
+			return credentials, dir, nil
+		}
+	}
+
+	return nil, "", err
+}
+
+// watchLockfiles starts watching lockfile directories if they are not watched yet
+func (client *DFFClient) watchLockfiles() {
+	if client.lockfiles != nil {
+		return
+	}
+
+	var err error
+	if client.lockfiles, err = lcu.WatchLockfiles(client.lockfileDirs()); err != nil {
+		client.Log.Debug(err)
+		client.Log.Debug("Could not watch lockfiles, falling back to polling")
+		client.lockfiles = nil
+	}
+}
+
+// closeLockfileWatcher stops watching lockfile directories
+func (client *DFFClient) closeLockfileWatcher() {
+	if client.lockfiles != nil {
+		if err := client.lockfiles.Close(); err != nil {
+			client.Log.Debug(err)
+		}
+		client.lockfiles = nil
+	}
+}
+
+// connect waits until the League client is running and creates the client API. Lockfile directories are
+// watched while waiting, so that DFF connects as soon as a lockfile is created.
+func (client *DFFClient) connect() {
+	for {
+		if credentials, lockfileDir, err := client.findClient(); err == nil {
+			client.api = lcu.NewClient(client.gameClient, credentials.Protocol, credentials.Port, credentials.Password)
+			client.lockfileDir = lockfileDir
+			return
+		}
+		client.Log.Info("Waiting for League process to open")
+
+		client.watchLockfiles()
+		if client.lockfiles == nil {
+			time.Sleep(client.pollInterval())
+			continue
+		}
+
+		select {
+		case event, ok := <-client.lockfiles.Events:
+			if !ok {
+				client.lockfiles = nil
+			} else {
+				client.Log.Debug("Lockfile changed in ", event.Dir)
+			}
+		case <-time.After(lockfileFallbackInterval):
+		}
+	}
+}
+
+// reconnect connects to the League client and gets the account, until the client API is functional.
+// Credentials are read again after failed attempts, as they change when the League client restarts.
+func (client *DFFClient) reconnect(observer Observer) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			delay := backoff(attempt - 1)
+			client.Log.Info("Client API is not ready. Retrying in ", delay)
+			observer.SetStatus("Connecting...")
+			time.Sleep(delay)
+		}
+
+		client.connect()
+		if err := client.getAccInfo(); err != nil {
+			client.Log.Debug(err)
+			continue
+		}
+		break
+	}
+
+	// Changes of lockfiles while connecting are not related to the connected League client
+	client.watchLockfiles()
+	if client.lockfiles != nil {
+		for len(client.lockfiles.Events) > 0 {
+			<-client.lockfiles.Events
+		}
+	}
+	client.lost = false
+}
+
+// connectionLost returns true if the League client was closed, or err shows that the League client
+// did not respond. Errors returned by the League client itself do not mean the connection is lost.
+func (client *DFFClient) connectionLost(err error) bool {
+	if client.lost {
+		return true
+	}
+	return lcu.IsConnectionError(err)
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/internal/lcu"
+	"github.com/jaeha-choi/DFF/internal/lcu/lcutest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConnectWaitsForLockfile(t *testing.T) {
+	client, server := newTestClient(t)
+	lockfile := filepath.Join(client.ClientDir, lcu.LockfileName)
+	if err := os.Remove(lockfile); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		client.connect()
+		close(done)
+	}()
+
+	// The lockfile is created after DFF starts watching
+	time.Sleep(200 * time.Millisecond)
+	if err := server.WriteLockfile(client.ClientDir); err != nil {
+		t.Fatal(err)
+	}
+
+	// Lockfiles are watched, so DFF connects before the fallback interval
+	select {
+	case <-done:
+	case <-time.After(lockfileFallbackInterval / 2):
+		t.Fatal("Lockfile creation is not detected")
+	}
+
+	if err := client.getAccInfo(); err != nil || client.account.SummonerID != 1234 {
+		t.Error("Could not connect to the client: ", err)
+	}
+}
+
+func TestFindClientSkipsStaleLockfile(t *testing.T) {
+	client, server := newTestClient(t)
+
+	// The League client crashed and left its lockfile
+	closed := lcutest.NewServer(datatype.AccountInfo{})
+	closed.Close()
+	if err := closed.WriteLockfile(client.ClientDir); err != nil {
+		t.Fatal(err)
+	}
+	if credentials, _, err := client.findClient(); err == nil && credentials.Port == closed.Port() {
+		t.Error("Stale lockfile is used")
+	}
+
+	if err := server.WriteLockfile(client.ClientDir); err != nil {
+		t.Fatal(err)
+	}
+	credentials, lockfileDir, err := client.findClient()
+	if err != nil || credentials.Port != server.Port() || lockfileDir != filepath.Clean(client.ClientDir) {
+		t.Error("Incorrect credentials: ", credentials, lockfileDir, err)
+	}
+}
+
+func TestLockfileOfOtherInstallRemoved(t *testing.T) {
+	client, _ := newTestClient(t)
+	client.connect()
+	events := make(chan lcu.LockfileEvent, 1)
+	client.lockfiles = &lcu.LockfileWatcher{Events: events}
+
+	events <- lcu.LockfileEvent{Dir: filepath.Join(client.ClientDir, "other"), Removed: true}
+	client.waitForUpdate(time.Second)
+	if client.lost {
+		t.Error("Removing a lockfile of another install should not lose the connection")
+	}
+
+	events <- lcu.LockfileEvent{Dir: client.lockfileDir, Removed: true}
+	client.waitForUpdate(time.Second)
+	if !client.lost {
+		t.Error("Removing the lockfile of the connected League client should lose the connection")
+	}
+}
+
+func TestRunReconnect(t *testing.T) {
+	client, server := newTestClient(t)
+
+	observer := &testObserver{}
+	done := make(chan struct{})
+	go func() {
+		client.Run(observer)
+		close(done)
+	}()
+
+	waitUntil(t, 5*time.Second, func() bool {
+		observer.mu.Lock()
+		defer observer.mu.Unlock()
+		return len(observer.statuses) > 0 && observer.statuses[len(observer.statuses)-1] == "Waiting..."
+	})
+
+	// The League client restarts with a new port and password
+	restarted := lcutest.NewServer(datatype.AccountInfo{AccountID: 5678, SummonerID: 1234})
+	defer restarted.Close()
+	restarted.AddChampion(datatype.Champion{ID: 103, Alias: "Ahri"})
+	restarted.SetQueueID(420)
+	restarted.SetSession(newTestSession(t, 1234, 103))
+	if err := restarted.WriteLockfile(client.ClientDir); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	waitUntil(t, 10*time.Second, func() bool {
+		_, ok := restarted.ItemPage(1234)
+		return ok
+	})
+
+	restarted.SetSession(nil)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after champion select")
+	}
+
+	reconnected := false
+	for _, status := range observer.statuses {
+		reconnected = reconnected || status == "Reconnecting..."
+	}
+	if !reconnected {
+		t.Error("Reconnection is not shown: ", observer.statuses)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	if backoff(0) != 500*time.Millisecond || backoff(2) != 2*time.Second || backoff(100) != maxBackoff {
+		t.Error("Incorrect backoff: ", backoff(0), backoff(2), backoff(100))
+	}
+}
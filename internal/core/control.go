@@ -0,0 +1,137 @@
+package core
+
+import (
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+)
+
+// Features are the parts of a build DFF sets automatically
+type Features struct {
+	EnableRune  bool `json:"enable_rune"`
+	EnableItem  bool `json:"enable_item"`
+	EnableSpell bool `json:"enable_spell"`
+}
+
+// selection is the build currently applied to the League client
+type selection struct {
+	championID int
+	mode       datatype.GameMode
+	position   cache.Position
+	runePage   *datatype.RunePage
+	runeIdx    int // index of the selected rune page option
+	itemSet    *datatype.ItemSet
+	itemIdx    int // index of the selected item set option
+	data       *cache.CachedData
+}
+
+// setSelection records the build currently applied to the League client
+func (client *DFFClient) setSelection(champId int, mode datatype.GameMode, position cache.Position,
+	runePage *datatype.RunePage, data *cache.CachedData) {
+	client.selectionMu.Lock()
+	defer client.selectionMu.Unlock()
+	client.selection = selection{championID: champId, mode: mode, position: position, runePage: runePage, data: data}
+	if data != nil && len(data.ItemPages.ItemSets) > 0 {
+		client.selection.itemSet = &data.ItemPages.ItemSets[0]
+	}
+}
+
+// setSelectedRunePage updates the rune page of the current selection, which is the idx-th option
+func (client *DFFClient) setSelectedRunePage(idx int, runePage *datatype.RunePage) {
+	client.selectionMu.Lock()
+	defer client.selectionMu.Unlock()
+	client.selection.runeIdx = idx
+	client.selection.runePage = runePage
+}
+
+// setSelectedItemSet updates the item set of the current selection, which is the idx-th option
+func (client *DFFClient) setSelectedItemSet(idx int, itemSet *datatype.ItemSet) {
+	client.selectionMu.Lock()
+	defer client.selectionMu.Unlock()
+	client.selection.itemIdx = idx
+	client.selection.itemSet = itemSet
+}
+
+// selectedOptions returns indices of the selected rune page and item set options
+func (client *DFFClient) selectedOptions() (runeIdx int, itemIdx int) {
+	client.selectionMu.Lock()
+	defer client.selectionMu.Unlock()
+	return client.selection.runeIdx, client.selection.itemIdx
+}
+
+// CurrentData returns build data applied to the current champion, or false if no champion is selected.
+// Returned data must not be modified.
+func (client *DFFClient) CurrentData() (*cache.CachedData, bool) {
+	client.selectionMu.Lock()
+	defer client.selectionMu.Unlock()
+	return client.selection.data, client.selection.data != nil
+}
+
+// Features returns parts of a build DFF currently sets
+func (client *DFFClient) Features() Features {
+	client.selectionMu.Lock()
+	defer client.selectionMu.Unlock()
+	return Features{
+		EnableRune:  client.EnableRune,
+		EnableItem:  client.EnableItem,
+		EnableSpell: client.EnableSpell,
+	}
+}
+
+// SetFeatures sets parts of a build DFF sets. Changes apply from the next champion.
+func (client *DFFClient) SetFeatures(features Features) {
+	client.selectionMu.Lock()
+	defer client.selectionMu.Unlock()
+	client.EnableRune = features.EnableRune
+	client.EnableItem = features.EnableItem
+	client.EnableSpell = features.EnableSpell
+}
+
+// RefreshCurrent discards cached build data of the current champion and retrieves it again
+func (client *DFFClient) RefreshCurrent() error {
+	client.selectionMu.Lock()
+	if client.selection.championID == 0 {
+		client.selectionMu.Unlock()
+		return noSelectionError
+	}
+	client.refresh = true
+	client.selectionMu.Unlock()
+
+	client.notify()
+	return nil
+}
+
+// choice is an option picked by the user on another goroutine, e.g. by the window or the control server.
+// It is applied by the session goroutine, which owns the champion select session.
+type choice struct {
+	idx    int
+	chosen bool // true if idx is not applied yet
+}
+
+// choose returns onSelect which records the option picked by the user in c. c must be guarded by selectionMu.
+func (client *DFFClient) choose(c *choice) func(idx int) {
+	return func(idx int) {
+		client.selectionMu.Lock()
+		*c = choice{idx: idx, chosen: true}
+		client.selectionMu.Unlock()
+
+		client.notify()
+	}
+}
+
+// takeChoice returns the option recorded in c once, or false if no option was picked
+func (client *DFFClient) takeChoice(c *choice) (idx int, ok bool) {
+	client.selectionMu.Lock()
+	defer client.selectionMu.Unlock()
+	idx, ok = c.idx, c.chosen
+	c.chosen = false
+	return idx, ok
+}
+
+// takeRefresh returns true once if RefreshCurrent was called
+func (client *DFFClient) takeRefresh() bool {
+	client.selectionMu.Lock()
+	defer client.selectionMu.Unlock()
+	refresh := client.refresh
+	client.refresh = false
+	return refresh
+}
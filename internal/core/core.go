@@ -1,14 +1,14 @@
 package core
 
 import (
-	"bytes"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"fyne.io/fyne/v2"
-	"fyne.io/fyne/v2/widget"
 	"github.com/jaeha-choi/DFF/internal/cache"
 	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/internal/lcu"
+	"github.com/jaeha-choi/DFF/internal/locale"
+	"github.com/jaeha-choi/DFF/internal/provider"
 	"github.com/jaeha-choi/DFF/pkg/log"
 	"io"
 	"io/ioutil"
@@ -17,6 +17,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -24,17 +25,38 @@ const ProjectName string = "DFF!"
 const Version string = "v0.6.2"
 const IssueUrl string = "https://github.com/jaeha-choi/DFF/issues"
 
+// CacheDir is the directory where cache files are saved
+const CacheDir = "cache"
+
+const cacheFileName = "cache.bin"
+const championListFileName = "positions.bin"
+
+// eventFallbackInterval is the polling interval used while the event stream is connected
+const eventFallbackInterval = 10 * time.Second
+
 type DFFClient struct {
-	apiPort     string
-	apiPass     string
-	apiProtocol string
 	Log         *log.Logger
 	gameClient  *http.Client
+	api         *lcu.Client
 	account     *datatype.AccountInfo
 	cache       *cache.Cache
 	metaInfo    *Meta
-	window      fyne.Window
+	provider    provider.BuildProvider
 	gameVersion string
+	events      *lcu.EventListener
+	lockfiles   *lcu.LockfileWatcher // watched while Run is running, nil if unavailable
+	lockfileDir string               // directory of the lockfile of the connected League client, if any
+	lost        bool                 // true if the League client was closed
+	wake        chan struct{}
+	overrides   *Overrides
+	selectionMu sync.Mutex // also guards EnableRune, EnableItem and EnableSpell. See Features.
+	selection   selection
+	refresh     bool   // guarded by selectionMu
+	roleChoice  choice // guarded by selectionMu
+	runeChoice  choice // guarded by selectionMu
+	itemChoice  choice // guarded by selectionMu
+	recorder    *lcu.RecordingTransport
+	tr          *locale.Translator
 
 	Debug       bool    `json:"debug"`
 	Interval    float64 `json:"interval"`
@@ -44,6 +66,34 @@ type DFFClient struct {
 	EnableSpell bool    `json:"enable_spell"`
 	DFlash      bool    `json:"d_flash"`
 	Language    string  `json:"language"`
+
+	// RunePageName is the template of rune page names. See runePageName for placeholders.
+	RunePageName string `json:"rune_page_name"`
+	// FontPath is a TTF font used by the window, e.g. for languages not supported by the default font
+	FontPath string `json:"font_path"`
+
+	// Region and Tier filter op.gg statistics. Empty values use op.gg defaults.
+	Region string `json:"region"`
+	Tier   string `json:"tier"`
+
+	// CacheCapacity is the number of champions kept in the cache
+	CacheCapacity int `json:"cache_capacity"`
+	// CacheExpiration is how many days cached build data is used, for each key of cacheModes
+	CacheExpiration map[string]int `json:"cache_expiration"`
+
+	SkillOrderBlock bool `json:"skill_order_block"`
+
+	ReuseRunePage       bool     `json:"reuse_rune_page"`
+	ProtectedRunePages  []string `json:"protected_rune_pages"`
+	AllowDeleteUserPage bool     `json:"allow_delete_user_page"`
+
+	ControlAddr string `json:"control_addr"`
+
+	RecordDir string `json:"record_dir"`
+
+	Offline      bool   `json:"offline"`
+	SnapshotPath string `json:"snapshot_path"`
+	SaveSnapshot bool   `json:"save_snapshot"`
 }
 
 type SpellFile struct {
@@ -56,8 +106,14 @@ type FileVersion struct {
 	Version string `json:"version"`
 }
 
-// Initialize creates DFFClient structure and initialize files/variables
+// Initialize creates DFFClient structure and initialize files/variables. op.gg is used for build data.
 func Initialize(outTo io.Writer) (client *DFFClient) {
+	return InitializeWithProvider(outTo, nil)
+}
+
+// InitializeWithProvider creates DFFClient structure which uses buildProvider for build data.
+// If buildProvider is nil, op.gg or the offline snapshot is used depending on the configuration.
+func InitializeWithProvider(outTo io.Writer, buildProvider provider.BuildProvider) (client *DFFClient) {
 	var err error
 	client = createDFFClient(outTo)
 
@@ -65,34 +121,54 @@ func Initialize(outTo io.Writer) (client *DFFClient) {
 		client.Log.Warning(ProjectName + " may not be initialized properly")
 	}
 
+	if client.tr, err = locale.Load(client.Language); err != nil {
+		client.Log.Debug(err)
+		client.Log.Warning("Translations for ", client.Language, " are not available. English will be used.")
+	}
+
+	client.enableRecording()
+
+	if buildProvider == nil {
+		buildProvider = client.createBuildProvider()
+	}
+	client.provider = buildProvider
+
 	if err = client.WriteConfig(); err != nil {
 		client.Log.Error("Could not write config file")
 	}
 
-	if err = os.MkdirAll("cache", 0700); err != nil {
+	if err = os.MkdirAll(CacheDir, 0700); err != nil {
 		client.Log.Debug(err)
 		client.Log.Error("Error while creating cache folder")
 	}
 
 	// Read/Download/Sync mandatory files if necessary
-	if err = client.checkFiles(); err != nil {
+	if client.Offline {
+		client.Log.Info("Offline mode is on. Skipping version check")
+	} else if err = client.checkFiles(); err != nil {
 		client.Log.Error("At least one mandatory file is missing")
 	}
 
-	if client.cache, err = cache.RestoreCache(filepath.Join("cache", "cache.bin"), client.gameVersion); err != nil {
+	client.loadOverrides()
+
+	if client.cache, err = cache.RestoreCache(filepath.Join(CacheDir, cacheFileName), client.gameVersion); err != nil {
 		client.Log.Debug(err)
 		client.Log.Warning("Could not restore cache, creating a new cache")
 		client.cache = cache.NewCache(client.gameVersion)
 	}
+	client.configureCache()
 
-	if err = client.restoreChampionList(filepath.Join("cache", "positions.bin"), client.gameVersion); err != nil {
+	if err = client.restoreChampionList(filepath.Join(CacheDir, championListFileName), client.gameVersion); err != nil {
 		client.Log.Debug(err)
 		client.Log.Warning("Could not restore position data, attempting to download new position data")
 		if !client.createChampionList(client.gameVersion) {
-			client.Log.Error("Failed to get champion list")
-			os.Exit(1)
-		}
-		if err = client.saveChampionList(filepath.Join("cache", "positions.bin")); err != nil {
+			if client.metaInfo == nil {
+				client.Log.Error("Failed to get champion list")
+				os.Exit(1)
+			}
+			// Positions rarely change between patches, so the saved list is better than none
+			client.Log.Warning("Using saved position data")
+		} else if err = client.saveChampionList(filepath.Join(CacheDir, championListFileName)); err != nil {
 			client.Log.Debug(err)
 			client.Log.Error("Failed to save champion list")
 		}
@@ -101,19 +177,84 @@ func Initialize(outTo io.Writer) (client *DFFClient) {
 	return client
 }
 
+// cacheModes are keys of CacheExpiration, and build modes they configure
+var cacheModes = map[string]datatype.GameMode{
+	"default": datatype.Default,
+	"aram":    datatype.Aram,
+	"urf":     datatype.Urf,
+	"arena":   datatype.Arena,
+}
+
+// defaultCacheExpiration returns the default expiration in days of each key of cacheModes
+func defaultCacheExpiration() map[string]int {
+	expiration := make(map[string]int, len(cacheModes))
+	for name := range cacheModes {
+		expiration[name] = int(cache.DefaultExpiration / (24 * time.Hour))
+	}
+	return expiration
+}
+
+// configureCache applies the bracket, capacity and expiration of the configuration to the cache
+func (client *DFFClient) configureCache() {
+	client.cache.Bracket = client.bracket()
+	client.cache.SetCapacity(client.CacheCapacity)
+	for name, days := range client.CacheExpiration {
+		client.cache.SetExpiration(cacheModes[name], time.Duration(days)*24*time.Hour)
+	}
+}
+
+// bracket returns the region and tier of build data, e.g. "kr/diamond_plus"
+func (client *DFFClient) bracket() string {
+	return client.Region + "/" + client.Tier
+}
+
+// createBuildProvider creates a build data provider based on the configuration.
+// In online mode, op.gg is used and the offline snapshot is used only if op.gg is unavailable.
+func (client *DFFClient) createBuildProvider() provider.BuildProvider {
+	offline, err := provider.NewOffline(client.SnapshotPath, client.bracket(), client.Log)
+	if err != nil {
+		client.Log.Debug(err)
+		client.Log.Info("Offline snapshot not found at ", client.SnapshotPath)
+	}
+
+	if client.Offline {
+		if offline == nil {
+			client.Log.Error("Offline mode requires a snapshot. Disable offline mode to create one")
+			os.Exit(1)
+		}
+		return offline
+	}
+
+	var online provider.BuildProvider = provider.NewOPGG(client.Log, client.Language, client.Region, client.Tier)
+	if client.SaveSnapshot {
+		if provider.IsArchive(client.SnapshotPath) {
+			client.Log.Warning("Cannot save snapshot to an archive: ", client.SnapshotPath)
+		} else {
+			online = provider.NewRecorder(online, client.SnapshotPath, client.bracket(), client.Log)
+		}
+	}
+
+	if offline == nil {
+		return online
+	}
+	return provider.NewFallback(online, offline, client.Log)
+}
+
 // createDFFClient initializes the DFF client and variables used by it
 func createDFFClient(outTo io.Writer) *DFFClient {
 	return &DFFClient{
-		apiPort:     "",
-		apiPass:     "",
-		apiProtocol: "",
-		Log:         log.NewLogger(outTo, log.INFO, ""),
+		Log: log.NewLogger(outTo, log.INFO, ""),
 		gameClient: &http.Client{Transport: &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 		}},
+		api:         nil, // initialized after reading lockfile
 		account:     nil,
 		cache:       nil, // must be initialized later
-		window:      nil,
+		provider:    nil, // must be initialized later
+		events:      nil,
+		wake:        make(chan struct{}, 1),
+		overrides:   NewOverrides(),
+		tr:          english,
 		Debug:       false,
 		Interval:    2,
 		ClientDir:   "C:/Riot Games/League of Legends/",
@@ -121,7 +262,30 @@ func createDFFClient(outTo io.Writer) *DFFClient {
 		EnableItem:  true,
 		EnableSpell: true,
 		DFlash:      true,
-		Language:    "en_US",
+		Language:    locale.English,
+
+		RunePageName: defaultRunePageName,
+		FontPath:     "",
+
+		Region: "",
+		Tier:   "",
+
+		CacheCapacity:   cache.DefaultCapacity,
+		CacheExpiration: defaultCacheExpiration(),
+
+		SkillOrderBlock: false,
+
+		ReuseRunePage:       false,
+		ProtectedRunePages:  []string{},
+		AllowDeleteUserPage: false,
+
+		ControlAddr: "",
+
+		RecordDir: "",
+
+		Offline:      false,
+		SnapshotPath: "snapshot",
+		SaveSnapshot: true,
 	}
 }
 
@@ -148,6 +312,26 @@ func (client *DFFClient) readConfig(filename string) (err error) {
 			client.Interval = 5
 		}
 
+		if !provider.IsOPGGRegion(client.Region) {
+			client.Log.Warning("Unknown region: ", client.Region, ". Default region will be used.")
+			client.Region = ""
+		}
+		if !provider.IsOPGGTier(client.Tier) {
+			client.Log.Warning("Unknown tier: ", client.Tier, ". Default tier will be used.")
+			client.Tier = ""
+		}
+
+		if client.CacheCapacity < 1 {
+			client.Log.Warning("Invalid cache capacity: ", client.CacheCapacity, ". Default capacity will be used.")
+			client.CacheCapacity = cache.DefaultCapacity
+		}
+		for name, days := range client.CacheExpiration {
+			if _, ok := cacheModes[name]; !ok || days < 1 {
+				client.Log.Warning("Invalid cache expiration: ", name, ": ", days, ". Default expiration will be used.")
+				delete(client.CacheExpiration, name)
+			}
+		}
+
 		if client.Debug {
 			client.Log.Mode = log.DEBUG
 		} else {
@@ -164,7 +348,10 @@ func (client *DFFClient) readConfig(filename string) (err error) {
 func (client *DFFClient) WriteConfig() (err error) {
 	var jsonConf []byte
 
-	if jsonConf, err = json.MarshalIndent(client, "", "\t"); err != nil {
+	client.selectionMu.Lock()
+	jsonConf, err = json.MarshalIndent(client, "", "\t")
+	client.selectionMu.Unlock()
+	if err != nil {
 		client.Log.Debug(err)
 		client.Log.Error("Error while encoding client to bytes")
 		return err
@@ -283,143 +470,115 @@ func (client *DFFClient) checkFiles() (err error) {
 	return err
 }
 
-// readLockFile wait for lockfile to be generated and reads "lockfile", which provides a token to access
-// the game client. Returns the content of lockfile as string or err if failed
-func (client *DFFClient) readLockFile() (err error) {
-	var file *os.File
-	// Loop until file is available
-	for {
-		if file, err = os.Open(client.ClientDir + "lockfile"); err == nil {
-			client.Log.Debug("lockfile found")
-			break
-		} else {
-			client.Log.Debug(err)
-			client.Log.Info("Waiting for League process to open")
-		}
-		time.Sleep(time.Duration(client.Interval) * time.Second)
-	}
-
-	b, err := ioutil.ReadAll(file)
+// subscribeEvents subscribes to champion select and gameflow events of the League client.
+// If the event stream is unavailable, DFF falls back to polling.
+func (client *DFFClient) subscribeEvents() {
+	var err error
+	client.events, err = client.api.Subscribe(lcu.ChampSelectSessionEvent, lcu.GameflowPhaseEvent)
 	if err != nil {
 		client.Log.Debug(err)
-		client.Log.Error("Error while reading a lockfile")
-		return err
+		client.Log.Warning("Could not subscribe to client events, falling back to polling")
+		client.events = nil
+		return
 	}
-
-	// lockfileValues[0] = not used
-	// lockfileValues[1] = not used
-	// lockfileValues[2] = Port
-	// lockfileValues[3] = API auth password (username is always "riot")
-	// lockfileValues[4] = Protocol (https)
-	lockfileValues := strings.Split(string(b), ":")
-
-	//for _, val := range lockfileValues {
-	//	client.Log.Debug(val)
-	//}
-
-	client.apiPort = lockfileValues[2]
-	client.apiPass = lockfileValues[3]
-	client.apiProtocol = lockfileValues[4]
-
-	return err
+	client.Log.Debug("Subscribed to client events")
 }
 
-// requestApi is a function interface for game client API
-func (client *DFFClient) requestApi(method string, command string, body io.Reader) *http.Response {
-	req, err := http.NewRequest(method, client.apiProtocol+"://127.0.0.1:"+client.apiPort+command, body)
-	if err != nil {
-		client.Log.Debug(err)
-		client.Log.Error("Error encountered while requesting information")
-		return nil
+// closeEvents closes the event stream if it is connected
+func (client *DFFClient) closeEvents() {
+	if client.events != nil {
+		if err := client.events.Close(); err != nil {
+			client.Log.Debug(err)
+		}
+		client.events = nil
 	}
-	req.SetBasicAuth("riot", client.apiPass)
+}
 
-	resp, err := client.gameClient.Do(req)
-	if err != nil {
-		client.Log.Debug(err)
-		client.Log.Error("Error while requesting API:", command)
-		return nil
+// pollInterval returns how long DFF waits between client API requests
+func (client *DFFClient) pollInterval() time.Duration {
+	if client.events != nil {
+		return eventFallbackInterval
 	}
-
-	return resp
+	return time.Duration(client.Interval * float64(time.Second))
 }
 
-// isInChampSelect returns true if the user is currently in a champion select phase, false otherwise
-func (client *DFFClient) isInChampSelect() (bool, error) {
-	command := "/lol-champ-select/v1/session"
-	var champSelect datatype.ChampSelect
-
-	err := json.NewDecoder(client.requestApi("GET", command, nil).Body).Decode(&champSelect)
-	if err != nil {
-		client.Log.Debug(err)
-		client.Log.Error("Error while decoding API response")
-		return false, err
+// notify wakes up DFF if it is waiting for an update
+func (client *DFFClient) notify() {
+	select {
+	case client.wake <- struct{}{}:
+	default:
 	}
-
-	return float64(champSelect.Timer.AdjustedTimeLeftInPhase) > client.Interval, err
 }
 
-// getAccInfo returns login information
-func (client *DFFClient) getAccInfo() (err error) {
-	command := "/lol-summoner/v1/current-summoner"
-
-	req, err := http.NewRequest("GET", client.apiProtocol+"://127.0.0.1:"+client.apiPort+(command), nil)
-	if err != nil {
-		client.Log.Debug(err)
-		client.Log.Error("Error encountered while requesting information")
-		return err
+// waitForUpdate blocks until the League client pushes an event, notify is called,
+// or timeout passes, whichever comes first
+func (client *DFFClient) waitForUpdate(timeout time.Duration) {
+	var events chan *lcu.Event
+	if client.events != nil {
+		events = client.events.Events
+	}
+	var lockfileEvents chan lcu.LockfileEvent
+	if client.lockfiles != nil {
+		lockfileEvents = client.lockfiles.Events
 	}
-	req.SetBasicAuth("riot", client.apiPass)
 
-	var resp *http.Response
-	// Repeat until API is functional
-	for {
-		if resp, err = client.gameClient.Do(req); err == nil && resp.StatusCode == 200 {
-			break
+	select {
+	case event, ok := <-lockfileEvents:
+		if !ok {
+			client.lockfiles = nil
+		} else if event.Removed && event.Dir == client.lockfileDir {
+			// Lockfiles of other installs are not related to the connected League client
+			client.Log.Info("League client closed")
+			client.lost = true
 		}
-		client.Log.Debug(err)
-		if resp != nil {
-			client.Log.Debug("Account Info Status code: ", resp.StatusCode)
+	case event, ok := <-events:
+		if !ok {
+			client.Log.Warning("Lost connection to client events, falling back to polling")
+			client.closeEvents()
+			return
 		}
-		time.Sleep(1 * time.Second)
+		client.Log.Debug("Event received: ", event.URI)
+		// Events often arrive in bursts, so only the latest state needs to be checked
+		for len(events) > 0 {
+			<-events
+		}
+	case <-client.wake:
+		client.Log.Debug("Woken up by user")
+	case <-time.After(timeout):
 	}
+}
 
-	if err = json.NewDecoder(resp.Body).Decode(&client.account); err != nil {
+// getAccInfo returns login information. The client API may not be ready right after the League client
+// starts, so requests are retried with exponential backoff up to accountAttempts times.
+func (client *DFFClient) getAccInfo() (err error) {
+	for attempt := 0; attempt < accountAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff(attempt - 1))
+		}
+		if client.account, err = client.api.GetCurrentSummoner(); err == nil {
+			client.Log.Info("Client API connection functional.")
+			return nil
+		}
 		client.Log.Debug(err)
-		client.Log.Error("Error while decoding account information")
-		return err
 	}
 
-	//client.Log.Debug("Logged in as...")
-	//client.Log.Debug("Account ID: ", client.account.AccountID)
-	//client.Log.Debug("Display Name: ", client.account.DisplayName)
-	//client.Log.Debug("Internal Name: ", client.account.InternalName)
-	//client.Log.Debug("Player UUID: ", client.account.Puuid)
-	//client.Log.Debug("Summoner ID: ", client.account.SummonerID)
-	client.Log.Info("Client API connection functional.")
-
-	return nil
+	return err
 }
 
-// checkIsInGame returns true if a user is currently in a game, false otherwise
-func (client *DFFClient) checkIsInGame() (bool, error) {
-	command := "/riotclient/ux-state"
-
-	bodyBytes, err := ioutil.ReadAll(client.requestApi("GET", command, nil).Body)
+// getGameflowPhase returns the gameflow phase of the League client
+func (client *DFFClient) getGameflowPhase() (string, error) {
+	phase, err := client.api.GetGameflowPhase()
 	if err != nil {
 		client.Log.Debug(err)
-		client.Log.Error("Error while checking if the user is in a game")
-		return false, err
+		client.Log.Error("Error while getting the gameflow phase")
+		return "", err
 	}
-	return string(bodyBytes) != "\"ShowMain\"", nil
+	return phase, nil
 }
 
 // getQueueId returns the type of the game (normal, urf, aram, etc)
 func (client *DFFClient) getQueueId() (int, error) {
-	command := "/lol-gameflow/v1/gameflow-metadata/player-status"
-	var queueInfo datatype.QueueInfo
-
-	err := json.NewDecoder(client.requestApi("GET", command, nil).Body).Decode(&queueInfo)
+	queueInfo, err := client.api.GetQueue()
 	if err != nil {
 		client.Log.Debug(err)
 		client.Log.Error("Error while getting the queue type")
@@ -428,145 +587,84 @@ func (client *DFFClient) getQueueId() (int, error) {
 	return queueInfo.CurrentLobbyStatus.QueueID, err
 }
 
-func (client *DFFClient) getChampId() (champId int, err error) {
-	command := "/lol-champ-select/v1/session"
-	var champSelect datatype.ChampSelect
-
-	if err = json.NewDecoder(client.requestApi("GET", command, nil).Body).Decode(&champSelect); err != nil {
-		client.Log.Debug(err)
-		client.Log.Error("Error while getting champion ID")
-		return 0, err
-	}
-
-	// Find current user's champion ID
-	for _, member := range champSelect.MyTeam {
-		if member.SummonerID == client.account.SummonerID {
-			champId = member.ChampionID
-			break
-		}
-	}
-
-	return champId, err
-}
-
-// deleteRunePageWithId deletes old rune page and return true if deleted, false otherwise
-func (client *DFFClient) deleteRunePageWithId(runePageId int) (bool, error) {
-	command := "/lol-perks/v1/pages/"
-
-	req, err := http.NewRequest("DELETE", client.apiProtocol+"://127.0.0.1:"+
-		client.apiPort+command+strconv.Itoa(runePageId), nil)
-	if err != nil {
-		client.Log.Debug(err)
-		client.Log.Error("Error while deleting an old DFF rune page")
-		return false, err
-	}
-	req.SetBasicAuth("riot", client.apiPass)
-
-	resp, err := client.gameClient.Do(req)
-	if err != nil {
-		client.Log.Debug(err)
-		client.Log.Error("Error while requesting to delete the old DFF rune page")
-		return false, err
-	}
-
-	return resp.StatusCode == http.StatusNoContent, nil
-}
-
-// setRunePage set a rune page
-func (client *DFFClient) setRunePage(page *datatype.RunePage) (bool, error) {
-	command := "/lol-perks/v1/pages"
-
-	if ok, err := client.delRunePage(); !ok || err != nil {
-		client.Log.Debug(err)
-		client.Log.Warning("Old rune page not deleted")
-	}
-
-	b := new(bytes.Buffer)
-	err := json.NewEncoder(b).Encode(page)
-	if err != nil {
-		client.Log.Debug(err)
-		client.Log.Error("Error in rune helper")
-		return false, err
-	}
-
-	req := client.requestApi("POST", command, b)
-	if req == nil || req.StatusCode != http.StatusOK {
-		client.Log.Debug(err)
-		client.Log.Error("Error while setting items")
-		return false, nil
+// retrieveItems sets item pages. An item set is created for each of the top core builds.
+func (client *DFFClient) retrieveItems(data *provider.Build, cachedData *cache.CachedData, champId int, gameType string) error {
+	if len(data.StarterItems) == 0 && len(data.CoreItems) == 0 && len(data.Boots) == 0 {
+		return newSectionError(itemsSection, "no item data")
 	}
 
-	if req.StatusCode == http.StatusOK {
-		client.Log.Debug("Rune page set")
-	}
-
-	return req.StatusCode == http.StatusOK, nil
-}
-
-// delRunePage deletes a rune page created by DFF, or the first rune page
-func (client *DFFClient) delRunePage() (deleted bool, err error) {
-	var runePages datatype.RunePages
-	var runePageCnt datatype.RunePageCount
-
-	command := "/lol-perks/v1/pages"
-	if err = json.NewDecoder(client.requestApi("GET", command, nil).Body).Decode(&runePages); err != nil {
-		client.Log.Debug(err)
-		client.Log.Error("Error while getting rune pages from the client")
-		return false, err
+	var skillBuildStr, firstThreeStr string
+	// Some modes (e.g. Arena) may not have skill data
+	if len(data.SkillMasteries) > 0 && len(data.SkillMasteries[0].Ids) >= 3 &&
+		len(data.SkillMasteries[0].Builds) > 0 && len(data.SkillMasteries[0].Builds[0].Order) >= 3 {
+		skillBuildStr = "Skill Tree: " +
+			data.SkillMasteries[0].Ids[0] + " -> " +
+			data.SkillMasteries[0].Ids[1] + " -> " +
+			data.SkillMasteries[0].Ids[2]
+
+		// First three skill tree
+		firstThreeStr = "First 3 skills: " +
+			data.SkillMasteries[0].Builds[0].Order[0] + " -> " +
+			data.SkillMasteries[0].Builds[0].Order[1] + " -> " +
+			data.SkillMasteries[0].Builds[0].Order[2]
 	}
 
-	command = "/lol-perks/v1/inventory"
-	if err = json.NewDecoder(client.requestApi("GET", command, nil).Body).Decode(&runePageCnt); err != nil {
-		client.Log.Debug(err)
-		client.Log.Error("Error while getting total rune pages count")
-		return false, err
+	// Create 3 or less item sets, at least one even without core items
+	variantCnt := min(len(data.CoreItems), 3)
+	if variantCnt == 0 {
+		variantCnt = 1
 	}
 
-	client.Log.Debug("Total Rune pages: ", len(runePages))
-
-	// Look for rune page starting with "DFF"
-	for _, page := range runePages {
-		//client.Log.Debug("Iterating rune page: " + page.Name)
-		if strings.HasPrefix(page.Name, ProjectName) {
-			if ok, err := client.deleteRunePageWithId(page.ID); ok && err == nil {
-				deleted = true
+	cachedData.ItemPages.AccountID = client.account.AccountID
+	cachedData.ItemPages.ItemSets = make([]datatype.ItemSet, variantCnt)
+	cachedData.ItemVariants = make([]datatype.DFFItemVariant, variantCnt)
+	for i := 0; i < variantCnt; i++ {
+		title := strings.TrimSpace(itemSetTitlePrefix + " " + gameType)
+		if len(data.CoreItems) > 0 {
+			core := data.CoreItems[i]
+			cachedData.ItemVariants[i] = datatype.DFFItemVariant{
+				PickRate:  core.PickRate * 100,
+				WinRate:   core.WinRate(),
+				SampleCnt: core.Play,
 			}
+			title = fmt.Sprintf("%s (%d) WR:%.1f%% Sample: %d", title, i+1, core.WinRate(), core.Play)
 		}
-	}
 
-	// Delete the first rune page if all pages are used (excluding 5 default rune pages)
-	if !deleted && len(runePages)+5 >= runePageCnt.OwnedPageCount {
-		if ok, err := client.deleteRunePageWithId(runePages[0].ID); ok && err == nil {
-			deleted = true
+		cachedData.ItemPages.ItemSets[i] = datatype.ItemSet{
+			AssociatedChampions: []int{champId},
+			AssociatedMaps:      []int{11, 12},
+			Blocks:              itemBlocks(data, i, firstThreeStr, skillBuildStr),
+			Map:                 "any",
+			Mode:                "any",
+			PreferredItemSlots:  []interface{}{},
+			Sortrank:            i,
+			StartedFrom:         "blank",
+			Title:               title,
+			Type:                "custom",
+			UID:                 itemSetUID(champId, i),
 		}
 	}
+	cachedData.ItemPages.Timestamp = 0
 
-	return deleted, nil
+	return nil
 }
 
-// retrieveItems sets an item page
-func (client *DFFClient) retrieveItems(data *datatype.OPGGChampData, cachedData *cache.CachedData, champId int, gameType string) (isSet bool) {
-	skillBuildStr := "Skill Tree: " +
-		data.SkillMasteries[0].Ids[0] + " -> " +
-		data.SkillMasteries[0].Ids[1] + " -> " +
-		data.SkillMasteries[0].Ids[2]
-
-	// First three skill tree
-	firstThreeStr := "First 3 skills: " +
-		data.SkillMasteries[0].Builds[0].Order[0] + " -> " +
-		data.SkillMasteries[0].Builds[0].Order[1] + " -> " +
-		data.SkillMasteries[0].Builds[0].Order[2]
-
-	// Create blocks: "Starter", "Core", "Boots", "Other items" (4 blocks)
+// itemBlocks creates blocks of an item set using coreIdx-th core items:
+// "Starter", "Core", "Boots", "Other items" (4 blocks)
+func itemBlocks(data *provider.Build, coreIdx int, firstThreeStr string, skillBuildStr string) []datatype.ItemBlock {
 	blockList := make([]datatype.ItemBlock, 4)
 	blockIdx := 0
 
+	// Items already in the item set, and other items in the order they are found
 	otherItemSet := make(map[int]bool)
-	willBeAdded := 0
+	var otherItems []int
 
 	// ---- Create Starter Items block
 	if len(data.StarterItems) > 0 {
-		title := "Starter Items (" + firstThreeStr + ")"
+		title := "Starter Items"
+		if firstThreeStr != "" {
+			title += " (" + firstThreeStr + ")"
+		}
 		// +1 to add a ward
 		itemList := make([]datatype.Item, len(data.StarterItems[0].Ids)+1)
 		for i, id := range data.StarterItems[0].Ids {
@@ -593,22 +691,26 @@ func (client *DFFClient) retrieveItems(data *datatype.OPGGChampData, cachedData
 	}
 
 	// ---- Create Core Items block
-	if len(data.CoreItems) > 0 {
-		title := "Core Items (" + skillBuildStr + ")"
-		itemList := make([]datatype.Item, len(data.CoreItems[0].Ids))
-		// Search up to max 5 core item blocks
+	if len(data.CoreItems) > coreIdx {
+		title := "Core Items"
+		if skillBuildStr != "" {
+			title += " (" + skillBuildStr + ")"
+		}
+		itemList := make([]datatype.Item, len(data.CoreItems[coreIdx].Ids))
+		for i, id := range data.CoreItems[coreIdx].Ids {
+			itemList[i] = datatype.Item{
+				Count: 1,
+				ID:    strconv.Itoa(id),
+			}
+			otherItemSet[id] = false
+		}
+		// Search up to max 5 core item blocks.
+		// If added to "Core Items" tab, don't add it to "Other Core Items" tab
 		for j := 0; j < min(len(data.CoreItems), 5); j++ {
-			for i, id := range data.CoreItems[j].Ids {
-				// If added to "Core Items" tab, don't add it to "Other Core Items" tab
-				if j == 0 {
-					itemList[i] = datatype.Item{
-						Count: 1,
-						ID:    strconv.Itoa(id),
-					}
-					otherItemSet[id] = false
-				} else if _, exist := otherItemSet[id]; !exist {
+			for _, id := range data.CoreItems[j].Ids {
+				if _, exist := otherItemSet[id]; !exist {
 					otherItemSet[id] = true
-					willBeAdded++
+					otherItems = append(otherItems, id)
 				}
 			}
 		}
@@ -627,7 +729,7 @@ func (client *DFFClient) retrieveItems(data *datatype.OPGGChampData, cachedData
 		for _, id := range data.LastItems[j].Ids {
 			if _, exist := otherItemSet[id]; !exist {
 				otherItemSet[id] = true
-				willBeAdded++
+				otherItems = append(otherItems, id)
 			}
 		}
 	}
@@ -636,12 +738,15 @@ func (client *DFFClient) retrieveItems(data *datatype.OPGGChampData, cachedData
 	if len(data.Boots) > 0 {
 		title := "Boots"
 		// Add 3 Boots
-		itemList := make([]datatype.Item, 3)
-		for j := 0; j < min(len(data.Boots), 3); j++ {
-			itemList[j] = datatype.Item{
+		itemList := make([]datatype.Item, 0, 3)
+		for j := 0; j < len(data.Boots) && len(itemList) < 3; j++ {
+			if len(data.Boots[j].Ids) == 0 {
+				continue
+			}
+			itemList = append(itemList, datatype.Item{
 				Count: 1,
 				ID:    strconv.Itoa(data.Boots[j].Ids[0]),
-			}
+			})
 		}
 		newItemBlock := datatype.ItemBlock{
 			HideIfSummonerSpell: "",
@@ -654,16 +759,11 @@ func (client *DFFClient) retrieveItems(data *datatype.OPGGChampData, cachedData
 	}
 
 	// ---- Create other core Items block
-	itemList := make([]datatype.Item, willBeAdded)
-	idx := 0
-	for key, val := range otherItemSet {
-		if val {
-			newItem := datatype.Item{
-				Count: 1,
-				ID:    strconv.Itoa(key),
-			}
-			itemList[idx] = newItem
-			idx++
+	itemList := make([]datatype.Item, len(otherItems))
+	for i, id := range otherItems {
+		itemList[i] = datatype.Item{
+			Count: 1,
+			ID:    strconv.Itoa(id),
 		}
 	}
 	newItemBlock := datatype.ItemBlock{
@@ -675,34 +775,17 @@ func (client *DFFClient) retrieveItems(data *datatype.OPGGChampData, cachedData
 	blockList[blockIdx] = newItemBlock
 	blockIdx++
 
-	cachedData.ItemPages.AccountID = client.account.AccountID
-	cachedData.ItemPages.ItemSets = []datatype.ItemSet{
-		{
-			AssociatedChampions: []int{champId},
-			AssociatedMaps:      []int{11, 12},
-			Blocks:              blockList,
-			Map:                 "any",
-			Mode:                "any",
-			PreferredItemSlots:  []interface{}{},
-			Sortrank:            0,
-			StartedFrom:         "blank",
-			Title:               ProjectName + " Item Page " + gameType,
-			Type:                "custom",
-			UID:                 "",
-		},
-	}
-	cachedData.ItemPages.Timestamp = 0
-
-	return true
+	return blockList[:blockIdx]
 }
 
 // retrieveSpells sets spells
-func (client *DFFClient) retrieveSpells(data *datatype.OPGGChampData, cachedData *cache.CachedData) (isSet bool) {
-	if len(data.SummonerSpells) < 1 {
-		return false
+func (client *DFFClient) retrieveSpells(data *provider.Build, cachedData *cache.CachedData) error {
+	if len(data.SummonerSpells) < 1 || len(data.SummonerSpells[0].Ids) < 2 {
+		return newSectionError(spellsSection, "no summoner spell data")
 	}
 
-	spellKeyList := data.SummonerSpells[0].Ids
+	// Copy to keep build data unchanged
+	spellKeyList := []int{data.SummonerSpells[0].Ids[0], data.SummonerSpells[0].Ids[1]}
 
 	// If user is using D key as flash, set flash for D
 	// If user is using F key as flash, set flash for F
@@ -718,19 +801,82 @@ func (client *DFFClient) retrieveSpells(data *datatype.OPGGChampData, cachedData
 	cachedData.Spells.Spell1ID = int64(spellKeyList[0])
 	cachedData.Spells.Spell2ID = int64(spellKeyList[1])
 
-	return true
+	return nil
+}
+
+// retrieveSkills sets skill orders. Missing skill data is not an error.
+func (client *DFFClient) retrieveSkills(data *provider.Build, cachedData *cache.CachedData) {
+	var mastery []string
+	if len(data.SkillMasteries) > 0 {
+		mastery = data.SkillMasteries[0].Ids
+	}
+
+	// Use orders from the skill mastery if full orders are not available
+	skills := data.Skills
+	if len(skills) == 0 && len(data.SkillMasteries) > 0 {
+		skills = data.SkillMasteries[0].Builds
+	}
+
+	// Create 3 or less skill orders
+	cachedData.SkillOrders = make([]datatype.DFFSkillOrder, min(len(skills), 3))
+	for i := 0; i < len(cachedData.SkillOrders); i++ {
+		cachedData.SkillOrders[i] = datatype.DFFSkillOrder{
+			PickRate:  skills[i].PickRate * 100,
+			WinRate:   skills[i].WinRate(),
+			SampleCnt: skills[i].Play,
+			Mastery:   mastery,
+			Order:     skills[i].Order,
+		}
+	}
+}
+
+// addSkillOrderBlock returns a copy of data with the most preferred skill order added to every item set.
+// data is not modified.
+func (client *DFFClient) addSkillOrderBlock(data *cache.CachedData) *cache.CachedData {
+	if len(data.SkillOrders) == 0 || len(data.ItemPages.ItemSets) == 0 {
+		return data
+	}
+
+	block := datatype.ItemBlock{
+		Items: []datatype.Item{},
+		Type:  "Skill Order: " + strings.Join(data.SkillOrders[0].Order, " "),
+	}
+
+	applied := *data
+	applied.ItemPages.ItemSets = append([]datatype.ItemSet{}, data.ItemPages.ItemSets...)
+	for i := range applied.ItemPages.ItemSets {
+		itemSet := &applied.ItemPages.ItemSets[i]
+		itemSet.Blocks = append(append([]datatype.ItemBlock{}, itemSet.Blocks...), block)
+	}
+
+	return &applied
 }
 
 // retrieveRunes will parse runes and make a RuneNamePage structure
-func (client *DFFClient) retrieveRunes(data *datatype.OPGGChampData, cachedData *cache.CachedData, champName string, gameType string) (isSet bool) {
+func (client *DFFClient) retrieveRunes(data *provider.Build, cachedData *cache.CachedData, champName string, gameType string) error {
+	// Rune pages need 4 primary runes, 2 secondary runes and 3 stat mods
+	runeOptions := make([]provider.RuneOption, 0, len(data.RunePages))
+	for _, page := range data.RunePages {
+		if len(page.PrimaryRuneIds) != 4 || len(page.SecondaryRuneIds) != 2 || len(page.StatModIds) != 3 ||
+			page.PrimaryStyleID == 0 || page.SubStyleID == 0 {
+			client.Log.Debug("Invalid rune page: ", page)
+			client.Log.Warning("Runes updated? Please submit a new issue at " + IssueUrl)
+			continue
+		}
+		runeOptions = append(runeOptions, page)
+	}
+
 	// Create 4 or less pages
-	cachedData.RunePages = make([]datatype.DFFRunePage, min(len(data.RunePages), 4))
+	cachedData.RunePages = make([]datatype.DFFRunePage, min(len(runeOptions), 4))
+	if len(runeOptions) == 0 {
+		return newSectionError(runesSection, "no valid rune page")
+	}
 
 	// Getting Pick rate/Win rate/Sample count
 	for i := 0; i < len(cachedData.RunePages); i++ {
-		cachedData.RunePages[i].PickRate = data.RunePages[i].PickRate * 100
-		cachedData.RunePages[i].WinRate = float64(data.RunePages[i].Win) / float64(data.RunePages[i].Play) * 100
-		cachedData.RunePages[i].SampleCnt = data.RunePages[i].Play
+		cachedData.RunePages[i].PickRate = runeOptions[i].PickRate * 100
+		cachedData.RunePages[i].WinRate = runeOptions[i].WinRate()
+		cachedData.RunePages[i].SampleCnt = runeOptions[i].Play
 	}
 
 	// Creating rune page name
@@ -742,13 +888,8 @@ func (client *DFFClient) retrieveRunes(data *datatype.OPGGChampData, cachedData
 	for i := 0; i < len(cachedData.RunePages); i++ {
 		runeList := make([]int, 9)
 
-		if len(runeList) != 9 {
-			client.Log.Error("Runes updated? Please submit a new issue at " + IssueUrl)
-			return false
-		}
-
 		idx := 0
-		currPage := data.RunePages[i].Builds[0]
+		currPage := runeOptions[i]
 
 		for _, id := range currPage.PrimaryRuneIds {
 			runeList[idx] = id
@@ -776,137 +917,135 @@ func (client *DFFClient) retrieveRunes(data *datatype.OPGGChampData, cachedData
 			LastModified:           0,
 			Name:                   ProjectName + " " + cachedData.RunePages[i].Name + " " + gameType,
 			Order:                  0,
-			PrimaryStyleID:         data.RunePages[i].PrimaryPageID,
+			PrimaryStyleID:         runeOptions[i].PrimaryStyleID,
 			SelectedPerkIds:        runeList,
-			SubStyleID:             data.RunePages[i].SecondaryPageID,
+			SubStyleID:             runeOptions[i].SubStyleID,
 		}
 	}
 
-	return true
+	return nil
 }
 
-func (client *DFFClient) retrieveData(gameMode datatype.GameMode, champion *datatype.Champion, champLabel *widget.Label, position cache.Position) (cacheData *cache.CachedData, pos cache.Position, ok bool) {
-	var gameType string
+// applyModeRules returns a copy of data adjusted for mode. data is not modified.
+func (client *DFFClient) applyModeRules(mode *datatype.ModeInfo, data *cache.CachedData) *cache.CachedData {
+	applied := *data
 
-	champLabel.SetText(champion.Alias)
-	client.Log.Debug("Selected Champion: ", champion.Alias)
-
-	// Normal mode, no specified position
-	if gameMode == datatype.Default && position == cache.None {
-		champMeta := client.metaInfo.Existing[champion.ID]
-		if champMeta == nil {
-			client.Log.Error("champMeta returns nil")
-			return nil, 0, false
-		}
-		// "RIP" champions use Top as a default position
-		if champMeta.IsRip || len(champMeta.Positions) == 0 {
-			client.Log.Info(champion.Alias, " does not have enough sample count.")
-			position = cache.Top
-		} else {
-			// Other champions use most frequently used position as a default position
-			position = champMeta.Positions[0].Position
+	applied.ItemPages.ItemSets = make([]datatype.ItemSet, len(data.ItemPages.ItemSets))
+	for i, itemSet := range data.ItemPages.ItemSets {
+		itemSet.AssociatedMaps = mode.MapIDs
+		if mode.Mode != mode.BuildMode {
+			// Build data of another mode is used
+			itemSet.Title = strings.Replace(itemSet.Title, itemSetTitlePrefix, itemSetTitlePrefix+" "+mode.Name, 1)
 		}
+		applied.ItemPages.ItemSets[i] = itemSet
 	}
 
+	return &applied
+}
+
+func (client *DFFClient) retrieveData(mode *datatype.ModeInfo, champion *datatype.Champion, observer Observer, position cache.Position) (cacheData *cache.CachedData, pos cache.Position, problems []error, ok bool) {
+	gameMode := mode.BuildMode
+
+	observer.SetChampion(champion.Alias)
+	client.Log.Debug("Selected Champion: ", champion.Alias)
+
 	cacheData, isCached := client.cache.GetPut(champion.ID, gameMode, position)
+	if cacheData == nil {
+		client.Log.Error("Unsupported game mode: ", mode.Name)
+		return nil, cache.None, nil, false
+	}
 	client.Log.Debug("Using cache: ", isCached)
 	if !isCached {
-		switch gameMode {
-		case datatype.Aram:
-			gameType = "ARAM"
-			client.Log.Info("ARAM MODE IS ON!!!")
-			cacheData.URL = "https://na.op.gg/modes/aram/" + champion.Alias + "/build"
-		case datatype.Urf:
-			gameType = "URF"
-			client.Log.Info("ULTRA RAPID FIRE MODE IS ON!!!")
-			cacheData.URL = "https://na.op.gg/modes/urf/" + champion.Alias + "/build"
-		case datatype.Default:
-			cacheData.URL = "https://op.gg/champions/" + champion.Alias + "/" + position.String() + "/build"
+		// Name of the mode whose build data is used
+		gameType := datatype.LookupMode(gameMode).Name
+		if gameType != "" {
+			client.Log.Info(gameType, " MODE IS ON!!!")
 		}
 
 		cacheData.CreationTime = time.Now()
-		data, ok := client.getFromJson(cacheData.URL)
-		if !ok {
-			client.Log.Debug("error while getting data from ", cacheData.URL)
-			return nil, cache.None, false
-		}
-
-		champData := data.Props.PageProps.Data
-
-		isSet := client.retrieveRunes(&champData, cacheData, champion.Alias, gameType)
-		if !isSet {
-			client.Log.Error("Error while retrieving rune page")
-			return nil, cache.None, false
+		champData, err := client.provider.Build(champion, gameMode, position)
+		if err != nil {
+			client.Log.Debug(err)
+			client.Log.Debug("error while getting build data for ", champion.Alias)
+			return nil, cache.None, nil, false
 		}
-
-		isSet = client.retrieveItems(&champData, cacheData, champion.ID, gameType)
-		if !isSet {
-			client.Log.Error("Error while retrieving item page")
-			return nil, cache.None, false
+		cacheData.URL = champData.Source
+
+		if problems = client.convertBuild(mode, champData, cacheData, champion, gameType); len(problems) > 0 {
+			// Partial data is not cached, so that it is retrieved again next time
+			partial := *cacheData
+			client.cache.Invalidate(champion.ID, gameMode, position)
+			cacheData = &partial
+			// Sections not set in this mode are only missing from the cache
+			if problems = usedProblems(mode, problems); len(problems) > 0 {
+				client.Log.Warning("Some build data is not available: ", statusText(problems))
+			}
 		}
+	}
 
-		isSet = client.retrieveSpells(&champData, cacheData)
-		if !isSet {
-			client.Log.Error("Error while retrieving spell page")
-			return nil, cache.None, false
-		}
+	cacheData = client.applyModeRules(mode, cacheData)
+	if client.SkillOrderBlock {
+		cacheData = client.addSkillOrderBlock(cacheData)
+	}
+	cacheData = client.applyNames(cacheData, runePageFields{
+		champion: champion.Alias,
+		role:     position.String(),
+		mode:     datatype.LookupMode(gameMode).Name,
+	})
+
+	if override := client.overrides.Get(champion.ID, mode.Mode, position); override != nil {
+		client.Log.Info("Applying user override for ", champion.Alias)
+		cacheData = override.Apply(cacheData)
+	}
+	if len(cacheData.RunePages) > 0 {
+		client.setSelection(champion.ID, mode.Mode, position, &cacheData.RunePages[0].Page, cacheData)
+	} else {
+		client.setSelection(champion.ID, mode.Mode, position, nil, cacheData)
 	}
 
-	if client.EnableRune {
-		if ok, err := client.setRunePage(&cacheData.RunePages[0].Page); !ok || err != nil {
+	// Sections are applied independently, so that other sections are applied even if one fails
+	features := client.Features()
+	if features.EnableRune && mode.SetRunes && len(cacheData.RunePages) > 0 {
+		if ok, err := client.setRunePage(&cacheData.RunePages[0].Page); err == noRunePageSlotError {
+			client.Log.Error("Unable to set a rune page")
+			problems = append(problems, newSectionError(runesSection, "no free rune page slot"))
+		} else if !ok || err != nil {
 			client.Log.Debug(err)
 			client.Log.Error("Unable to set a rune page")
-			return nil, cache.None, false
+			problems = append(problems, newSectionError(runesSection, "could not set a rune page"))
 		}
 	}
 
-	if client.EnableItem {
-		command := "/lol-item-sets/v1/item-sets/" + strconv.Itoa(client.account.SummonerID) + "/sets"
-
-		b := new(bytes.Buffer)
-		err := json.NewEncoder(b).Encode(cacheData.ItemPages)
-		if err != nil {
+	if features.EnableItem && len(cacheData.ItemPages.ItemSets) > 0 {
+		if err := client.setItemSets(&cacheData.ItemPages, 0); err != nil {
 			client.Log.Debug(err)
 			client.Log.Error("Error while setting items")
-			return nil, cache.None, false
-		}
-
-		req := client.requestApi("PUT", command, b)
-		if req == nil || req.StatusCode != http.StatusCreated {
-			client.Log.Debug(err)
-			client.Log.Error("Error while setting items")
-			return nil, cache.None, false
+			problems = append(problems, newSectionError(itemsSection, "could not set item sets"))
+		} else {
+			client.Log.Debug("Item page set")
 		}
-		client.Log.Debug("Item page set")
 	}
 
-	if client.EnableSpell {
-		b := new(bytes.Buffer)
-		err := json.NewEncoder(b).Encode(cacheData.Spells)
-		if err != nil {
+	if features.EnableSpell && mode.SetSpells && cacheData.Spells.Spell1ID != 0 {
+		if err := client.api.PatchMySelection(&cacheData.Spells); err != nil {
 			client.Log.Debug(err)
 			client.Log.Error("Error while setting spells")
-			return nil, cache.None, false
-		}
-
-		command := "/lol-champ-select/v1/session/my-selection"
-		req := client.requestApi("PATCH", command, b)
-		if req == nil || req.StatusCode != http.StatusNoContent {
-			client.Log.Debug(err)
-			client.Log.Error("Error while setting spells")
-			return nil, cache.None, false
+			problems = append(problems, newSectionError(spellsSection, "could not set spells"))
+		} else {
+			client.Log.Debug("Spells set")
 		}
-		client.Log.Debug("Spells set")
 	}
 
-	return cacheData, position, true
+	return cacheData, position, problems, true
 }
 
-// Run starts DFF
-func (client *DFFClient) Run(window fyne.Window, status *widget.Label, p *widget.Select, champLabel *widget.Label, runeSelect *widget.Select) {
+// Run starts DFF and returns once a game ends. Status updates are reported to observer.
+func (client *DFFClient) Run(observer Observer) {
+	defer client.saveRecording()
 	defer func() {
+		client.Log.Info("Cache ", client.cache.Stats)
 		client.Log.Debug("Saving cache...")
-		err := client.cache.SaveCache(filepath.Join("cache", "cache.bin"))
+		err := client.cache.SaveCache(filepath.Join(CacheDir, cacheFileName))
 		client.Log.Debug("Cache saved")
 		if err != nil {
 			client.Log.Debug(err)
@@ -915,163 +1054,32 @@ func (client *DFFClient) Run(window fyne.Window, status *widget.Label, p *widget
 		}
 	}()
 
-	var err error
-
-	status.SetText("Starting...")
-	champLabel.SetText("Not selected")
-
-	if err = client.readLockFile(); err != nil {
-		status.SetText("Error. Check log")
-		window.RequestFocus()
-		return
-	}
-
-	if err = client.getAccInfo(); err != nil {
-		status.SetText("Error. Check log")
-		window.RequestFocus()
-		return
-	}
-
-	//var isCustomGame = false
-	var prevChampId, champId int
-	var queueId = -1
+	observer.SetStatus("Starting...")
+	observer.SetChampion("Not selected")
 
-	// Check if in lobby
-	for champId == 0 {
-		status.SetText("Waiting...")
-		client.Log.Debug("Waiting for a champion to be selected...")
-		if queueId, err = client.getQueueId(); err != nil {
-			status.SetText("Error. Check log")
-			window.RequestFocus()
-		}
-		if champId, err = client.getChampId(); err != nil {
-			status.SetText("Error. Check log")
-			window.RequestFocus()
-		}
-		time.Sleep(time.Duration(client.Interval) * time.Second)
-	}
-
-	var gameMode datatype.GameMode
-	if queueId == int(datatype.Aram) || queueId == int(datatype.Urf) {
-		gameMode = datatype.GameMode(queueId)
-	} else {
-		gameMode = datatype.Default
-	}
-
-	var cachedData *cache.CachedData
-	var ok bool
-
-	lastRole := cache.None
-	position := cache.None
-	positionIdx := 0
-	var isInChampSelect = true
-	for isInChampSelect {
-		if isInChampSelect, err = client.isInChampSelect(); err != nil {
-			status.SetText("Error. Check log")
-			window.RequestFocus()
-		}
-
-		if champId, err = client.getChampId(); err != nil {
-			status.SetText("Error. Check log")
-			window.RequestFocus()
+	defer client.closeLockfileWatcher()
+	for {
+		client.reconnect(observer)
+		client.subscribeEvents()
+		err := client.session(observer)
+		client.closeEvents()
+		if err != connectionLostError {
+			return
 		}
 
-		if champId != 0 && prevChampId != champId || lastRole != position {
-			if prevChampId != champId {
-				position = cache.None
-				positionIdx = 0
-			}
-
-			// Convert champ id to datatype.Champion
-			var champion datatype.Champion
-			command := "/lol-champions/v1/inventories/" + strconv.Itoa(client.account.SummonerID) + "/champions/" + strconv.Itoa(champId)
-			err = json.NewDecoder(client.requestApi("GET", command, nil).Body).Decode(&champion)
-			if err != nil {
-				client.Log.Debug(err)
-				client.Log.Error("Error while getting runes")
-				status.SetText("Error. Check log")
-				if client.window != nil {
-					client.window.RequestFocus()
-				}
-			}
-
-			status.SetText("Setting...")
-			cachedData, position, ok = client.retrieveData(gameMode, &champion, champLabel, position)
-			if !ok {
-				status.SetText("Error. Check log")
-				if client.window != nil {
-					client.window.RequestFocus()
-				}
-			} else {
-				status.SetText("Updated...")
-			}
-			lastRole = position
-
-			if ok && len(cachedData.RunePages) > 0 {
-				runeSelect.Options = make([]string, len(cachedData.RunePages))
-				for x, elem := range cachedData.RunePages {
-					runeSelect.Options[x] = fmt.Sprintf("%d. PR:%.1f%% WR:%.1f%% Sample: %d", x+1, elem.PickRate, elem.WinRate, elem.SampleCnt)
-				}
-				runeSelect.Selected = runeSelect.Options[0]
-				runeSelect.OnChanged = func(s string) {
-					client.Log.Debug("Alternative rune selected")
-					endI := strings.Index(s, ". ")
-					i, _ := strconv.Atoi(s[:endI])
-					ok, err := client.setRunePage(&cachedData.RunePages[i-1].Page)
-					if !ok || err != nil {
-						status.SetText("Error. Check log")
-						if client.window != nil {
-							client.window.RequestFocus()
-						}
-					}
-
-				}
-				runeSelect.Refresh()
-			}
-
-			if gameMode == datatype.Default {
-				p.Options = make([]string, len(client.metaInfo.Existing[champion.ID].Positions))
-				for i := 0; i < len(client.metaInfo.Existing[champion.ID].Positions); i++ {
-					p.Options[i] += client.metaInfo.Existing[champion.ID].Positions[i].Position.String() + " - " +
-						client.metaInfo.Existing[champion.ID].Positions[i].RoleRate
-				}
-
-				p.Selected = p.Options[positionIdx]
-				p.OnChanged = func(s string) {
-					var res string
-					for positionIdx, res = range p.Options {
-						if s == res {
-							position = client.metaInfo.Existing[champion.ID].Positions[positionIdx].Position
-							break
-						}
-					}
-				}
-				p.Refresh()
-			} else {
-				p.PlaceHolder = "No alternative role available."
-				p.Refresh()
-			}
-			prevChampId = champId
-		}
-		client.Log.Debug("Checking if Champion ID was updated...")
-		time.Sleep(time.Duration(client.Interval) * time.Second)
+		// State of the session is not kept, as it is read again from the League client
+		client.Log.Warning("Lost connection to the League client. Reconnecting...")
+		observer.SetStatus("Reconnecting...")
+		client.clearSession(observer)
 	}
-	p.Options = nil
-	p.Refresh()
-
-	status.SetText("Idle...")
+}
 
-	var isInGame bool
-	for {
-		if isInGame, err = client.checkIsInGame(); err != nil {
-			status.SetText("Error. Check log")
-		}
-		if !isInGame {
-			break
-		}
-		time.Sleep(30 * time.Second)
-		client.Log.Debug("In game: ", isInGame)
-	}
+// clearSession clears options of the champion select session
+func (client *DFFClient) clearSession(observer Observer) {
+	observer.SetRoles(nil, -1, nil)
+	observer.SetItemSets(nil, -1, nil)
+	observer.SetSkillOrders(nil)
+	client.setSelection(0, datatype.Default, cache.None, nil, nil)
 }
 
 //command := "/lol-summoner/v1/current-summoner" // returns login information
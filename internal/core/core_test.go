@@ -0,0 +1,363 @@
+package core
+
+import (
+	"encoding/json"
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/internal/lcu/lcutest"
+	"github.com/jaeha-choi/DFF/internal/provider"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeProvider returns the same build for every champion
+type fakeProvider struct {
+	champions []provider.ChampionInfo
+	builds    int32 // number of Build calls
+}
+
+func (f *fakeProvider) ChampionList() ([]provider.ChampionInfo, error) {
+	return f.champions, nil
+}
+
+func (f *fakeProvider) Build(champion *datatype.Champion, mode datatype.GameMode, position cache.Position) (*provider.Build, error) {
+	atomic.AddInt32(&f.builds, 1)
+	return &provider.Build{
+		Source: "fake/" + champion.Alias + "/" + position.String(),
+		RunePages: []provider.RuneOption{
+			{
+				Stats:            provider.Stats{Play: 100, Win: 55, PickRate: 0.4},
+				PrimaryStyleID:   8100,
+				SubStyleID:       8300,
+				PrimaryRuneIds:   []int{8112, 8139, 8138, 8135},
+				SecondaryRuneIds: []int{8345, 8347},
+				StatModIds:       []int{5008, 5008, 5002},
+			},
+		},
+		StarterItems:   []provider.ItemOption{{Ids: []int{1056, 2003}}},
+		CoreItems:      []provider.ItemOption{{Ids: []int{6655, 3020, 4645}}, {Ids: []int{3165}}},
+		Boots:          []provider.ItemOption{{Ids: []int{3020}}},
+		LastItems:      []provider.ItemOption{{Ids: []int{3089}}},
+		SummonerSpells: []provider.SpellOption{{Ids: []int{14, 4}}},
+		Skills: []provider.SkillOption{
+			{
+				Stats: provider.Stats{Play: 80, Win: 44, PickRate: 0.5},
+				Order: []string{"Q", "W", "E", "Q", "Q", "R", "Q", "W", "Q", "W", "R", "W", "W", "E", "E", "R", "E", "E"},
+			},
+		},
+		SkillMasteries: []provider.SkillMastery{
+			{
+				Ids:    []string{"Q", "W", "E"},
+				Builds: []provider.SkillOption{{Order: []string{"Q", "W", "E"}}},
+			},
+		},
+		Opponents: []provider.OpponentStats{{Stats: provider.Stats{Play: 200, Win: 104}, ChampionID: 238}},
+	}, nil
+}
+
+// MatchupBuild returns the same build with a different keystone
+func (f *fakeProvider) MatchupBuild(champion *datatype.Champion, opponent *datatype.Champion, mode datatype.GameMode, position cache.Position) (*provider.Build, error) {
+	build, err := f.Build(champion, mode, position)
+	if err != nil {
+		return nil, err
+	}
+	build.Source += "/vs/" + opponent.Alias
+	build.RunePages[0].PrimaryRuneIds = []int{8128, 8139, 8138, 8135}
+	build.CoreItems = []provider.ItemOption{{Stats: provider.Stats{Play: 50, Win: 30}, Ids: []int{3157}}}
+	return build, nil
+}
+
+// testObserver records updates from DFFClient
+type testObserver struct {
+	mu           sync.Mutex
+	statuses     []string
+	champion     string
+	roles        []string
+	onRole       func(int)
+	runePages    []string
+	runeSelected int
+	onRune       func(int)
+	itemSets     []string
+	itemSelected int
+	onItemSet    func(int)
+	skillLog     [][]datatype.DFFSkillOrder
+}
+
+func (o *testObserver) SetStatus(status string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.statuses = append(o.statuses, status)
+}
+
+func (o *testObserver) SetChampion(name string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.champion = name
+}
+
+func (o *testObserver) SetRoles(roles []string, selected int, onSelect func(idx int)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.roles = roles
+	o.onRole = onSelect
+}
+
+func (o *testObserver) SetRunePages(pages []string, selected int, onSelect func(idx int)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.runePages = pages
+	o.runeSelected = selected
+	o.onRune = onSelect
+}
+
+func (o *testObserver) SetItemSets(itemSets []string, selected int, onSelect func(idx int)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if itemSets != nil {
+		o.itemSets = itemSets
+		o.itemSelected = selected
+		o.onItemSet = onSelect
+	}
+}
+
+func (o *testObserver) SetSkillOrders(orders []datatype.DFFSkillOrder) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.skillLog = append(o.skillLog, orders)
+}
+
+func (o *testObserver) RequestAttention() {}
+
+func TestAddSkillOrderBlock(t *testing.T) {
+	client := createDFFClient(ioutil.Discard)
+	data := &cache.CachedData{
+		ItemPages:   datatype.ItemPage{ItemSets: []datatype.ItemSet{{Blocks: []datatype.ItemBlock{{Type: "Boots"}}}}},
+		SkillOrders: []datatype.DFFSkillOrder{{Order: []string{"Q", "E", "W"}}},
+	}
+
+	applied := client.addSkillOrderBlock(data)
+	if blocks := applied.ItemPages.ItemSets[0].Blocks; len(blocks) != 2 || blocks[1].Type != "Skill Order: Q E W" {
+		t.Error("Incorrect item blocks: ", blocks)
+	}
+	if len(data.ItemPages.ItemSets[0].Blocks) != 1 {
+		t.Error("Original data is modified")
+	}
+}
+
+func TestRefreshCurrent(t *testing.T) {
+	client, server := newTestClient(t)
+	fake := client.provider.(*fakeProvider)
+
+	server.AddChampion(datatype.Champion{ID: 103, Alias: "Ahri"})
+	server.SetQueueID(420)
+	server.SetRunePages(nil, 10)
+	server.SetSession(newTestSession(t, 1234, 103))
+
+	if err := client.RefreshCurrent(); err != noSelectionError {
+		t.Error("Refreshing without a selection should fail: ", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		client.Run(&testObserver{})
+		close(done)
+	}()
+
+	waitUntil(t, 5*time.Second, func() bool {
+		data, ok := client.CurrentData()
+		return ok && data.URL == "fake/Ahri/Mid"
+	})
+	if err := client.RefreshCurrent(); err != nil {
+		t.Fatal(err)
+	}
+	waitUntil(t, 5*time.Second, func() bool {
+		return atomic.LoadInt32(&fake.builds) == 2
+	})
+
+	server.SetSession(nil)
+	<-done
+}
+
+// newTestSession creates a blind pick champion select session where summonerId picked champId
+func newTestSession(t *testing.T, summonerId int, champId int) *datatype.ChampSelect {
+	return newDraftSession(t, summonerId, champId, "")
+}
+
+// newDraftSession creates a champion select session where summonerId picked champId in assignedPosition
+func newDraftSession(t *testing.T, summonerId int, champId int, assignedPosition string) *datatype.ChampSelect {
+	var session datatype.ChampSelect
+	b := []byte(`{"myTeam": [{"summonerId": ` + strconv.Itoa(summonerId) + `, "championId": ` + strconv.Itoa(champId) +
+		`, "assignedPosition": "` + assignedPosition + `"}], "timer": {"adjustedTimeLeftInPhase": 30000, "phase": "BAN_PICK"}}`)
+	if err := json.Unmarshal(b, &session); err != nil {
+		t.Fatal(err)
+	}
+	return &session
+}
+
+// newTestClient creates DFFClient connected to a fake League client. Current directory is changed
+// to a temporary directory, as cache files are saved to the current directory.
+func newTestClient(t *testing.T) (*DFFClient, *lcutest.Server) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+	})
+	if err = os.MkdirAll("cache", 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	server := lcutest.NewServer(datatype.AccountInfo{AccountID: 5678, SummonerID: 1234})
+	t.Cleanup(server.Close)
+	if err = server.WriteLockfile(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	client := createDFFClient(ioutil.Discard)
+	client.ClientDir = dir + "/"
+	client.Interval = 0.05
+	client.provider = &fakeProvider{}
+	client.cache = cache.NewCache("test")
+	client.metaInfo = &Meta{
+		Existing: map[int]*MetaChampion{
+			103: {Positions: []MetaPosition{{Position: cache.Mid, RoleRate: "Pick rate: 90.0%"}}},
+		},
+	}
+
+	return client, server
+}
+
+// waitUntil polls cond until it returns true, or fails the test after timeout
+func waitUntil(t *testing.T, timeout time.Duration, cond func() bool) {
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRun(t *testing.T) {
+	client, server := newTestClient(t)
+
+	server.AddChampion(datatype.Champion{ID: 103, Alias: "Ahri"})
+	server.SetQueueID(420)
+	server.SetRunePages(datatype.RunePages{{ID: 1, Name: "My page", IsDeletable: true}}, 10)
+	server.SetSession(newTestSession(t, 1234, 103))
+
+	observer := &testObserver{}
+	done := make(chan struct{})
+	go func() {
+		client.Run(observer)
+		close(done)
+	}()
+
+	waitUntil(t, 5*time.Second, func() bool {
+		_, ok := server.ItemPage(1234)
+		return ok && server.Selection().Spell1ID != 0
+	})
+
+	// An item set is created for each core build
+	itemPage, _ := server.ItemPage(1234)
+	if itemPage.AccountID != 5678 || len(itemPage.ItemSets) != 2 || itemPage.ItemSets[0].AssociatedChampions[0] != 103 ||
+		!strings.HasPrefix(itemPage.ItemSets[0].Title, ProjectName+" Item Page (1)") {
+		t.Error("Incorrect item page: ", itemPage)
+	}
+
+	observer.mu.Lock()
+	itemOptions, onItemSet := observer.itemSets, observer.onItemSet
+	observer.mu.Unlock()
+	if len(itemOptions) != 2 {
+		t.Fatal("Incorrect item set options: ", itemOptions)
+	}
+	// Picked item sets are applied by the session goroutine
+	onItemSet(1)
+	waitUntil(t, 5*time.Second, func() bool {
+		itemPage, _ = server.ItemPage(1234)
+		return len(itemPage.ItemSets) == 2 && strings.HasPrefix(itemPage.ItemSets[0].Title, ProjectName+" Item Page (2)")
+	})
+	if itemPage.ItemSets[1].Sortrank != 1 {
+		t.Error("Selected item set is not shown first: ", itemPage)
+	}
+
+	// Leave champion select
+	server.SetSession(nil)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after champion select")
+	}
+
+	if observer.champion != "Ahri" {
+		t.Error("Incorrect champion: ", observer.champion)
+	}
+
+	if last := observer.statuses[len(observer.statuses)-1]; last != "Idle..." {
+		t.Error("Incorrect status: ", last)
+	}
+
+	pages := server.RunePages()
+	if len(pages) != 2 || !strings.HasPrefix(pages[0].Name, ProjectName+" Ahri (1)") || pages[1].Name != "My page" {
+		t.Error("Incorrect rune pages: ", pages)
+	}
+
+	// Flash is moved to D
+	if spells := server.Selection(); spells.Spell1ID != 4 || spells.Spell2ID != 14 {
+		t.Error("Incorrect spells: ", spells)
+	}
+
+	if len(observer.runePages) != 1 {
+		t.Error("Incorrect rune options: ", observer.runePages)
+	}
+
+	if len(observer.skillLog) < 2 || len(observer.skillLog[0]) != 1 || len(observer.skillLog[0][0].Order) != 18 ||
+		observer.skillLog[0][0].Mastery[0] != "Q" || observer.skillLog[len(observer.skillLog)-1] != nil {
+		t.Error("Incorrect skill orders: ", observer.skillLog)
+	}
+
+	if _, isCached := client.cache.GetPut(103, datatype.Default, cache.Mid); !isCached {
+		t.Error("Build data is not cached")
+	}
+
+	if _, err := os.Stat("cache/cache.bin"); err != nil {
+		t.Error("Cache is not saved: ", err)
+	}
+}
+
+func TestConfigureCache(t *testing.T) {
+	client := createDFFClient(ioutil.Discard)
+	filename := filepath.Join(t.TempDir(), "config.json")
+	config := `{"cache_capacity": 4, "cache_expiration": {"aram": 1, "urf": 0, "unknown": 3}}`
+	if err := ioutil.WriteFile(filename, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.readConfig(filename); err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid entries use the default expiration
+	expiration := client.CacheExpiration
+	if len(expiration) != 3 || expiration["aram"] != 1 || expiration["default"] != 7 || expiration["arena"] != 7 {
+		t.Error("Incorrect cache expiration: ", expiration)
+	}
+
+	client.cache = cache.NewCache("test")
+	client.configureCache()
+	if client.cache.Capacity != 4 || client.cache.Expiration[datatype.Aram] != 24*time.Hour ||
+		client.cache.Expiration[datatype.Urf] != 0 {
+		t.Error("Configuration is not applied: ", client.cache.Capacity, client.cache.Expiration)
+	}
+}
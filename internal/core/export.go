@@ -0,0 +1,70 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// CacheExportVersion is used to keep track of exported cache file versions.
+// If cacheExport is edited in any way, this value must be incremented.
+const CacheExportVersion uint16 = 1
+
+var incompatibleExportError = errors.New("exported cache is incompatible")
+
+// cacheExport is the JSON representation of every cache file
+type cacheExport struct {
+	Version uint16       `json:"version"`
+	Cache   *cache.Cache `json:"cache,omitempty"`
+	Meta    *Meta        `json:"meta,omitempty"`
+}
+
+// ExportCache writes cache files in dir to w as JSON. Missing cache files are skipped.
+func ExportCache(dir string, w io.Writer) (err error) {
+	export := cacheExport{Version: CacheExportVersion}
+
+	if export.Cache, err = cache.ReadCache(filepath.Join(dir, cacheFileName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if export.Meta, err = readMeta(filepath.Join(dir, championListFileName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(&export)
+}
+
+// ImportCache reads cache exported by ExportCache from r and overwrites cache files in dir
+func ImportCache(dir string, r io.Reader) (err error) {
+	var export cacheExport
+	if err = json.NewDecoder(r).Decode(&export); err != nil {
+		return err
+	}
+
+	if export.Version != CacheExportVersion {
+		return incompatibleExportError
+	}
+
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	if export.Cache != nil {
+		if err = export.Cache.SaveCache(filepath.Join(dir, cacheFileName)); err != nil {
+			return err
+		}
+	}
+
+	if export.Meta != nil {
+		if err = writeMeta(filepath.Join(dir, championListFileName), export.Meta); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
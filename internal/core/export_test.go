@@ -0,0 +1,65 @@
+package core
+
+import (
+	"bytes"
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExportImportCache(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+
+	c := cache.NewCache("12.1.1")
+	data, _ := c.GetPut(103, datatype.Default, cache.Mid)
+	data.CreationTime = time.Now().Round(0)
+	data.URL = "someURL"
+	c.GetPut(7, datatype.Aram, cache.None)
+	if err := c.SaveCache(filepath.Join(src, cacheFileName)); err != nil {
+		t.Fatal(err)
+	}
+
+	meta := &Meta{
+		CreationTime:      time.Now().Round(0),
+		CacheVersion:      ChampListDataVersion,
+		GameClientVersion: "12.1.1",
+		Existing: map[int]*MetaChampion{
+			103: {Positions: []MetaPosition{{Position: cache.Mid, RoleRate: "Pick rate: 90.0%"}}},
+		},
+	}
+	if err := writeMeta(filepath.Join(src, championListFileName), meta); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportCache(src, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := ImportCache(dst, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := cache.RestoreCache(filepath.Join(dst, cacheFileName), "12.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.String() != "7\t103\t" {
+		t.Error("Incorrect cache order: ", restored.String())
+	}
+	if restoredData := restored.Existing[103].Value.Default[cache.Mid]; restoredData.URL != "someURL" ||
+		!restoredData.CreationTime.Equal(data.CreationTime) {
+		t.Error("Incorrect cached data")
+	}
+
+	restoredMeta, err := readMeta(filepath.Join(dst, championListFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !restoredMeta.CreationTime.Equal(meta.CreationTime) || !reflect.DeepEqual(restoredMeta.Existing, meta.Existing) {
+		t.Error("Incorrect meta")
+	}
+}
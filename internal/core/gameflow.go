@@ -0,0 +1,316 @@
+package core
+
+import (
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"time"
+)
+
+// Gameflow phases of the League client, returned by /lol-gameflow/v1/gameflow-phase
+const (
+	phaseNone                  = "None"
+	phaseLobby                 = "Lobby"
+	phaseMatchmaking           = "Matchmaking"
+	phaseCheckedIntoTournament = "CheckedIntoTournament"
+	phaseReadyCheck            = "ReadyCheck"
+	phaseChampSelect           = "ChampSelect"
+	phaseGameStart             = "GameStart"
+	phaseFailedToLaunch        = "FailedToLaunch"
+	phaseInProgress            = "InProgress"
+	phaseReconnect             = "Reconnect"
+	phaseWaitingForStats       = "WaitingForStats"
+	phasePreEndOfGame          = "PreEndOfGame"
+	phaseEndOfGame             = "EndOfGame"
+	phaseTerminatedInError     = "TerminatedInError"
+)
+
+// inGameInterval is how often DFF checks if the game ended without client events, as nothing is set in game
+const inGameInterval = 30 * time.Second
+
+// sessionState is the state of DFF while following the gameflow of the League client
+type sessionState int
+
+const (
+	// stateWaiting is in the lobby or in the queue, waiting for champion select
+	stateWaiting sessionState = iota
+	// stateChampSelect is in champion select
+	stateChampSelect
+	// stateInGame is playing or spectating a game
+	stateInGame
+	// stateDone is after the game or champion select ended. The session is over.
+	stateDone
+)
+
+func (s sessionState) String() string {
+	switch s {
+	case stateWaiting:
+		return "Waiting"
+	case stateChampSelect:
+		return "ChampSelect"
+	case stateInGame:
+		return "InGame"
+	case stateDone:
+		return "Done"
+	default:
+		return "Unknown"
+	}
+}
+
+// nextState returns the state after the League client reports phase in state
+func nextState(state sessionState, phase string) sessionState {
+	if state == stateDone {
+		return stateDone
+	}
+
+	switch phase {
+	case phaseChampSelect:
+		return stateChampSelect
+	case phaseGameStart, phaseInProgress, phaseReconnect, phaseWaitingForStats, phasePreEndOfGame:
+		// Games started without champion select are spectated or were started before DFF
+		return stateInGame
+	case phaseEndOfGame, phaseFailedToLaunch, phaseTerminatedInError:
+		if state == stateWaiting {
+			// Game of a previous session
+			return stateWaiting
+		}
+		return stateDone
+	case phaseNone:
+		if state == stateWaiting {
+			return stateWaiting
+		}
+		// The lobby was left
+		return stateDone
+	default:
+		if state == stateInGame {
+			return stateDone
+		}
+		// Lobby and queue phases. Champion select was dodged if it was in champion select.
+		return stateWaiting
+	}
+}
+
+// champSelect is the state of a champion select session
+type champSelect struct {
+	mode           *datatype.ModeInfo
+	prevChampId    int
+	champion       *datatype.Champion
+	cachedData     *cache.CachedData
+	ok             bool // true if cachedData was retrieved
+	lastRole       cache.Position
+	position       cache.Position
+	positionIdx    int
+	positions      []MetaPosition         // role options
+	runePages      []datatype.DFFRunePage // rune page options
+	itemPage       datatype.ItemPage      // item set options
+	source         positionSource
+	lastOpponentId int
+}
+
+// session follows the gameflow of the League client until the game or champion select ends.
+// connectionLostError is returned if the connection to the League client is lost.
+func (client *DFFClient) session(observer Observer) (err error) {
+	state := stateWaiting
+	var selection *champSelect
+
+	observer.SetStatus("Waiting...")
+	client.Log.Debug("Waiting for champion select...")
+	for {
+		var phase string
+		if phase, err = client.getGameflowPhase(); client.connectionLost(err) {
+			return connectionLostError
+		} else if err != nil {
+			observer.SetStatus("Error. Check log")
+			observer.RequestAttention()
+			client.waitForUpdate(client.pollInterval())
+			continue
+		}
+
+		if next := nextState(state, phase); next != state {
+			client.Log.Debug("Gameflow phase: ", phase, ", ", state, " -> ", next)
+			if selection, err = client.enterState(observer, state, next); client.connectionLost(err) {
+				return connectionLostError
+			}
+			state = next
+		}
+
+		switch state {
+		case stateChampSelect:
+			if err = client.updateChampSelect(observer, selection); client.connectionLost(err) {
+				return connectionLostError
+			}
+			client.Log.Debug("Checking if Champion ID was updated...")
+			client.waitForUpdate(client.pollInterval())
+		case stateInGame:
+			if client.events != nil {
+				client.waitForUpdate(client.pollInterval())
+			} else {
+				client.waitForUpdate(inGameInterval)
+			}
+		case stateDone:
+			return nil
+		default:
+			client.waitForUpdate(client.pollInterval())
+		}
+	}
+}
+
+// enterState updates the observer when the state changes from prev to state.
+// A new champion select session is returned when state is stateChampSelect.
+func (client *DFFClient) enterState(observer Observer, prev sessionState, state sessionState) (*champSelect, error) {
+	if prev == stateChampSelect {
+		client.clearSession(observer)
+	}
+
+	switch state {
+	case stateWaiting:
+		if prev == stateChampSelect {
+			client.Log.Info("Champion select ended without a game")
+		}
+		observer.SetStatus("Waiting...")
+	case stateChampSelect:
+		observer.SetStatus("Waiting...")
+		queueId, err := client.getQueueId()
+		if err != nil {
+			observer.SetStatus("Error. Check log")
+			observer.RequestAttention()
+		}
+		mode := datatype.ModeOfQueue(queueId)
+		client.Log.Debug("Queue ID: ", queueId, ", mode: ", mode.Mode)
+		return &champSelect{mode: mode, lastRole: cache.None, position: cache.None, source: positionSelected}, err
+	case stateInGame:
+		if prev != stateChampSelect {
+			client.Log.Info("Game in progress without champion select")
+		}
+		observer.SetStatus("Idle...")
+	case stateDone:
+		observer.SetStatus("Idle...")
+	}
+
+	return nil, nil
+}
+
+// updateChampSelect applies build data when the champion, role or lane opponent changes in champion select
+func (client *DFFClient) updateChampSelect(observer Observer, s *champSelect) (err error) {
+	champId, assigned, enemies, err := client.getMyPlayer()
+	if err != nil {
+		observer.SetStatus("Error. Check log")
+		observer.RequestAttention()
+		return err
+	}
+
+	// Options are picked on other goroutines, and applied here
+	if idx, ok := client.takeChoice(&client.roleChoice); ok && idx < len(s.positions) {
+		s.positionIdx = idx
+		s.position = s.positions[idx].Position
+		s.source = positionSelected
+	}
+	if idx, ok := client.takeChoice(&client.runeChoice); ok && idx < len(s.runePages) {
+		client.Log.Debug("Alternative rune selected")
+		client.applyRunePage(observer, s.runePages, idx)
+	}
+	if idx, ok := client.takeChoice(&client.itemChoice); ok && idx < len(s.itemPage.ItemSets) {
+		client.Log.Debug("Alternative item set selected")
+		client.applyItemSet(observer, &s.itemPage, idx)
+	}
+
+	refresh := client.takeRefresh()
+	if champId != 0 && s.prevChampId != champId || s.lastRole != s.position || champId != 0 && refresh {
+		if s.prevChampId != champId {
+			s.position = cache.None
+			s.positionIdx = 0
+		} else if refresh {
+			client.Log.Info("Refreshing build data")
+			client.cache.Invalidate(champId, s.mode.BuildMode, s.position)
+		}
+
+		// Convert champ id to datatype.Champion
+		s.champion, err = client.api.GetChampion(client.account.SummonerID, champId)
+		if err != nil {
+			client.Log.Debug(err)
+			client.Log.Error("Error while getting champion information")
+			observer.SetStatus("Error. Check log")
+			observer.RequestAttention()
+			s.champion = &datatype.Champion{ID: champId}
+		}
+
+		if s.mode.HasPositions() && s.position == cache.None {
+			s.position, s.source = client.choosePosition(s.champion.ID, assigned)
+			client.Log.Debug("Position: ", s.position, " (", s.source, ")")
+		}
+
+		observer.SetStatus("Setting...")
+		var problems []error
+		s.cachedData, s.position, problems, s.ok = client.retrieveData(s.mode, s.champion, observer, s.position)
+		if !s.ok {
+			observer.SetStatus("Error. Check log")
+			observer.RequestAttention()
+		} else {
+			observer.SetStatus(statusText(problems))
+			if len(problems) > 0 {
+				observer.RequestAttention()
+			}
+		}
+		s.lastRole = s.position
+
+		s.lastOpponentId = 0
+		s.runePages = nil
+		if s.ok && len(s.cachedData.RunePages) > 0 {
+			s.runePages = client.showRunePages(observer, s.cachedData.RunePages, nil)
+		}
+
+		if s.ok && hasItemSets(s.cachedData) {
+			s.itemPage = client.showItemSets(observer, s.cachedData.ItemPages, s.cachedData.ItemVariants, nil)
+		} else {
+			s.itemPage = datatype.ItemPage{}
+			observer.SetItemSets(nil, -1, nil)
+		}
+
+		if s.ok {
+			observer.SetSkillOrders(s.cachedData.SkillOrders)
+		} else {
+			observer.SetSkillOrders(nil)
+		}
+
+		if s.mode.HasPositions() {
+			s.positions = client.rolePositions(s.champion.ID, s.position)
+			options := make([]string, len(s.positions))
+			for i := 0; i < len(s.positions); i++ {
+				options[i] = s.positions[i].Position.String() + " - " + s.positions[i].RoleRate
+				if s.ok && s.positions[i].Position == s.position {
+					// Position may differ from the most frequently used one (e.g. assigned position)
+					s.positionIdx = i
+					if s.source != positionSelected {
+						options[i] += " (" + s.source.String() + ")"
+					}
+				}
+			}
+
+			observer.SetRoles(options, s.positionIdx, client.choose(&client.roleChoice))
+		} else {
+			s.positions = nil
+			observer.SetRoles(nil, -1, nil)
+		}
+		s.prevChampId = champId
+	}
+
+	// Lane opponent may be picked after the build data is applied
+	if s.ok && s.mode.HasPositions() && (len(s.cachedData.RunePages) > 0 || hasItemSets(s.cachedData)) {
+		if opponentId := client.laneOpponent(enemies, s.position); opponentId != s.lastOpponentId {
+			s.lastOpponentId = opponentId
+			matchup := client.retrieveMatchup(s.mode, s.champion, opponentId, s.position, s.cachedData)
+			if len(s.cachedData.RunePages) > 0 {
+				s.runePages = client.showRunePages(observer, s.cachedData.RunePages, matchup)
+			}
+			if hasItemSets(s.cachedData) {
+				s.itemPage = client.showItemSets(observer, s.cachedData.ItemPages, s.cachedData.ItemVariants, matchup)
+			}
+		}
+	}
+
+	return nil
+}
+
+// hasItemSets returns true if data has item sets with statistics to show as options
+func hasItemSets(data *cache.CachedData) bool {
+	return len(data.ItemPages.ItemSets) > 0 && len(data.ItemVariants) == len(data.ItemPages.ItemSets)
+}
@@ -0,0 +1,97 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNextState(t *testing.T) {
+	tests := []struct {
+		name  string
+		state sessionState
+		phase string
+		next  sessionState
+	}{
+		{name: "idle", state: stateWaiting, phase: phaseNone, next: stateWaiting},
+		{name: "lobby", state: stateWaiting, phase: phaseLobby, next: stateWaiting},
+		{name: "queue", state: stateWaiting, phase: phaseMatchmaking, next: stateWaiting},
+		{name: "ready check", state: stateWaiting, phase: phaseReadyCheck, next: stateWaiting},
+		{name: "tournament", state: stateWaiting, phase: phaseCheckedIntoTournament, next: stateWaiting},
+		{name: "champion select", state: stateWaiting, phase: phaseChampSelect, next: stateChampSelect},
+		{name: "still in champion select", state: stateChampSelect, phase: phaseChampSelect, next: stateChampSelect},
+		{name: "dodge to queue", state: stateChampSelect, phase: phaseMatchmaking, next: stateWaiting},
+		{name: "dodge to lobby", state: stateChampSelect, phase: phaseLobby, next: stateWaiting},
+		{name: "dodge to ready check", state: stateChampSelect, phase: phaseReadyCheck, next: stateWaiting},
+		{name: "lobby left in champion select", state: stateChampSelect, phase: phaseNone, next: stateDone},
+		{name: "game start", state: stateChampSelect, phase: phaseGameStart, next: stateInGame},
+		{name: "game in progress", state: stateChampSelect, phase: phaseInProgress, next: stateInGame},
+		{name: "failed to launch", state: stateChampSelect, phase: phaseFailedToLaunch, next: stateDone},
+		{name: "spectating", state: stateWaiting, phase: phaseInProgress, next: stateInGame},
+		{name: "started in game", state: stateWaiting, phase: phaseReconnect, next: stateInGame},
+		{name: "game reconnect", state: stateInGame, phase: phaseReconnect, next: stateInGame},
+		{name: "waiting for stats", state: stateInGame, phase: phaseWaitingForStats, next: stateInGame},
+		{name: "pre end of game", state: stateInGame, phase: phasePreEndOfGame, next: stateInGame},
+		{name: "end of game", state: stateInGame, phase: phaseEndOfGame, next: stateDone},
+		{name: "spectating ended", state: stateInGame, phase: phaseNone, next: stateDone},
+		{name: "game ended to lobby", state: stateInGame, phase: phaseLobby, next: stateDone},
+		{name: "terminated in error", state: stateInGame, phase: phaseTerminatedInError, next: stateDone},
+		{name: "end of previous game", state: stateWaiting, phase: phaseEndOfGame, next: stateWaiting},
+		{name: "unknown phase", state: stateWaiting, phase: "Unknown", next: stateWaiting},
+		{name: "done", state: stateDone, phase: phaseChampSelect, next: stateDone},
+	}
+
+	for _, test := range tests {
+		if next := nextState(test.state, test.phase); next != test.next {
+			t.Error("Incorrect state for ", test.name, ": ", test.state, " -> ", next)
+		}
+	}
+
+	// Champion select is dodged, then the ready check of the next queue is declined and the queue is left
+	state := stateWaiting
+	for _, step := range []struct {
+		phase string
+		next  sessionState
+	}{
+		{phase: phaseChampSelect, next: stateChampSelect},
+		{phase: phaseReadyCheck, next: stateWaiting},
+		{phase: phaseLobby, next: stateWaiting},
+		{phase: phaseMatchmaking, next: stateWaiting},
+		{phase: phaseChampSelect, next: stateChampSelect},
+	} {
+		if state = nextState(state, step.phase); state != step.next {
+			t.Error("Incorrect state after a dodge for ", step.phase, ": ", state)
+		}
+	}
+}
+
+func TestRunSpectating(t *testing.T) {
+	client, server := newTestClient(t)
+	server.SetPhase(phaseInProgress)
+
+	observer := &testObserver{}
+	done := make(chan struct{})
+	go func() {
+		client.Run(observer)
+		close(done)
+	}()
+
+	waitUntil(t, 5*time.Second, func() bool {
+		observer.mu.Lock()
+		defer observer.mu.Unlock()
+		return len(observer.statuses) > 0 && observer.statuses[len(observer.statuses)-1] == "Idle..."
+	})
+	server.SetPhase(phaseEndOfGame)
+	client.notify()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the game")
+	}
+
+	for _, request := range server.Requests() {
+		if strings.Contains(request, "/lol-champ-select/") || strings.Contains(request, "/lol-perks/") {
+			t.Error("Build data is set while spectating: ", request)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/internal/provider"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata/opgg")
+
+// goldenPages are saved op.gg pages in testdata/opgg with the mode used to convert them
+var goldenPages = []struct {
+	name     string
+	gameType string
+}{
+	{name: "ahri_mid", gameType: ""},
+	{name: "ahri_aram", gameType: "ARAM"},
+}
+
+// TestGoldenOPGG converts saved op.gg pages to CachedData and compares it with golden files.
+// Run with -update after an intended change to the conversion.
+func TestGoldenOPGG(t *testing.T) {
+	for _, page := range goldenPages {
+		page := page
+		t.Run(page.name, func(t *testing.T) {
+			b, err := ioutil.ReadFile(filepath.Join("testdata", "opgg", page.name+".html"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			build, _, err := provider.ParseOPGGBuild(string(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client := createDFFClient(ioutil.Discard)
+			client.account = &datatype.AccountInfo{AccountID: 5678, SummonerID: 1234}
+			data := &cache.CachedData{}
+			mode := datatype.LookupMode(datatype.Default)
+			if problems := client.convertBuild(mode, build, data, &datatype.Champion{ID: 103, Alias: "Ahri"}, page.gameType); problems != nil {
+				t.Fatal(problems)
+			}
+
+			actual, err := json.MarshalIndent(data, "", "\t")
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual = append(actual, '\n')
+
+			golden := filepath.Join("testdata", "opgg", page.name+".golden.json")
+			if *update {
+				if err = ioutil.WriteFile(golden, actual, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(actual, expected) {
+				t.Error("Converted data differs from ", golden, ":\n", diffLines(string(expected), string(actual)))
+			}
+		})
+	}
+}
+
+// diffLines returns the first line which differs between expected and actual
+func diffLines(expected string, actual string) string {
+	e, a := strings.Split(expected, "\n"), strings.Split(actual, "\n")
+	for i := 0; i < len(e) && i < len(a); i++ {
+		if e[i] != a[i] {
+			return "line " + strconv.Itoa(i+1) + ":\n- " + e[i] + "\n+ " + a[i]
+		}
+	}
+	return "line count " + strconv.Itoa(len(e)) + " != " + strconv.Itoa(len(a))
+}
@@ -0,0 +1,61 @@
+package core
+
+import (
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"strconv"
+	"strings"
+)
+
+// itemSetUIDPrefix is the prefix of UIDs of item sets created by DFF
+const itemSetUIDPrefix = "dff-"
+
+// itemSetUID returns a stable UID of idx-th item set of champId
+func itemSetUID(champId int, idx int) string {
+	return itemSetUIDPrefix + strconv.Itoa(champId) + "-" + strconv.Itoa(idx)
+}
+
+// itemSetTitlePrefix is the prefix of titles of item sets created by DFF
+const itemSetTitlePrefix = ProjectName + " Item Page"
+
+// isDFFItemSet returns true if itemSet was created by DFF. Item sets created by
+// older versions do not have a stable UID, so they are identified by the title.
+func isDFFItemSet(itemSet *datatype.ItemSet) bool {
+	return strings.HasPrefix(itemSet.UID, itemSetUIDPrefix) || strings.HasPrefix(itemSet.Title, itemSetTitlePrefix)
+}
+
+// setItemSets writes item sets of page to the League client, with the selected-th item set
+// shown first. Existing item sets are read first, and only the ones created by DFF are replaced.
+func (client *DFFClient) setItemSets(page *datatype.ItemPage, selected int) error {
+	existing, err := client.api.GetItemSets(client.account.SummonerID)
+	if err != nil {
+		return err
+	}
+
+	merged := datatype.ItemPage{
+		AccountID: page.AccountID,
+		ItemSets:  make([]datatype.ItemSet, 0, len(existing.ItemSets)+len(page.ItemSets)),
+		Timestamp: page.Timestamp,
+	}
+
+	// Selected item set is placed first
+	for i, itemSet := range page.ItemSets {
+		if i == selected {
+			itemSet.Sortrank = 0
+			merged.ItemSets = append(merged.ItemSets, itemSet)
+		}
+	}
+	for i, itemSet := range page.ItemSets {
+		if i != selected {
+			itemSet.Sortrank = len(merged.ItemSets)
+			merged.ItemSets = append(merged.ItemSets, itemSet)
+		}
+	}
+
+	for _, itemSet := range existing.ItemSets {
+		if !isDFFItemSet(&itemSet) {
+			merged.ItemSets = append(merged.ItemSets, itemSet)
+		}
+	}
+
+	return client.api.PutItemSets(client.account.SummonerID, &merged)
+}
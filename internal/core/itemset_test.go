@@ -0,0 +1,62 @@
+package core
+
+import (
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"testing"
+)
+
+func TestSetItemSets(t *testing.T) {
+	client, server := newTestClient(t)
+	client.connect()
+	if err := client.getAccInfo(); err != nil {
+		t.Fatal(err)
+	}
+
+	server.SetItemPage(1234, datatype.ItemPage{
+		AccountID: 5678,
+		ItemSets: []datatype.ItemSet{
+			{Title: "My set", UID: "3f5ae2d0-user", Sortrank: 0},
+			{Title: "Renamed by user", UID: itemSetUID(1, 0), Sortrank: 1},
+			{Title: ProjectName + " Item Page URF", UID: "", Sortrank: 2},
+			{Title: ProjectName + " Item Page", UID: "0a9e44b1-old", Sortrank: 2},
+			{Title: ProjectName + " lookalike", UID: "7c1b90aa-user", Sortrank: 3},
+		},
+	})
+
+	page := &datatype.ItemPage{
+		AccountID: 5678,
+		ItemSets: []datatype.ItemSet{
+			{Title: ProjectName + " Item Page (1)", UID: itemSetUID(103, 0)},
+			{Title: ProjectName + " Item Page (2)", UID: itemSetUID(103, 1)},
+		},
+	}
+	if err := client.setItemSets(page, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	itemPage, _ := server.ItemPage(1234)
+	var uids []string
+	for _, itemSet := range itemPage.ItemSets {
+		uids = append(uids, itemSet.UID)
+	}
+
+	// Item sets created by the user survive, and ones created by DFF are replaced
+	expected := []string{itemSetUID(103, 1), itemSetUID(103, 0), "3f5ae2d0-user", "7c1b90aa-user"}
+	if len(uids) != len(expected) {
+		t.Fatal("Incorrect item sets: ", uids)
+	}
+	for i := range expected {
+		if uids[i] != expected[i] {
+			t.Error("Incorrect item sets: ", uids)
+			break
+		}
+	}
+
+	// Setting again does not duplicate item sets
+	if err := client.setItemSets(page, 0); err != nil {
+		t.Fatal(err)
+	}
+	if itemPage, _ = server.ItemPage(1234); len(itemPage.ItemSets) != 4 || itemPage.ItemSets[0].UID != itemSetUID(103, 0) {
+		t.Error("Incorrect item sets: ", itemPage.ItemSets)
+	}
+}
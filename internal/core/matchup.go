@@ -0,0 +1,214 @@
+package core
+
+import (
+	"fmt"
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/internal/provider"
+)
+
+// matchupBuild is build data against the lane opponent
+type matchupBuild struct {
+	opponent string
+	stats    *datatype.DFFMatchup    // nil if the matchup is not in build data
+	page     *datatype.DFFRunePage   // nil if the matchup does not have runes
+	itemSet  *datatype.ItemSet       // nil if the matchup does not have items
+	variant  datatype.DFFItemVariant // statistics of itemSet
+}
+
+// laneOpponent returns the champion ID of the enemy in position, or 0 if it is not visible yet.
+// Enemy positions are hidden in ranked, so the most played position of each enemy is used if necessary.
+func (client *DFFClient) laneOpponent(enemies []enemyPick, position cache.Position) int {
+	if position == cache.None {
+		return 0
+	}
+
+	for _, enemy := range enemies {
+		if enemy.assigned == position {
+			return enemy.champId
+		}
+	}
+
+	for _, enemy := range enemies {
+		if enemy.assigned != cache.None {
+			continue
+		}
+		if champMeta := client.metaInfo.Existing[enemy.champId]; champMeta != nil && len(champMeta.Positions) > 0 &&
+			champMeta.Positions[0].Position == position {
+			return enemy.champId
+		}
+	}
+
+	return 0
+}
+
+// retrieveMatchups sets win rates against opponents
+func (client *DFFClient) retrieveMatchups(data *provider.Build, cachedData *cache.CachedData) {
+	cachedData.Matchups = make([]datatype.DFFMatchup, 0, len(data.Opponents))
+	for _, opponent := range data.Opponents {
+		cachedData.Matchups = append(cachedData.Matchups, datatype.DFFMatchup{
+			ChampionID: opponent.ChampionID,
+			WinRate:    opponent.WinRate(),
+			SampleCnt:  opponent.Play,
+		})
+	}
+}
+
+// retrieveMatchup returns the most preferred rune page and item set of champion against opponentId, or nil if
+// the provider does not have matchup data. Matchup data is not cached.
+func (client *DFFClient) retrieveMatchup(mode *datatype.ModeInfo, champion *datatype.Champion, opponentId int,
+	position cache.Position, cachedData *cache.CachedData) *matchupBuild {
+	matchupProvider, ok := client.provider.(provider.MatchupProvider)
+	if !ok || opponentId == 0 {
+		return nil
+	}
+
+	opponent, err := client.api.GetChampion(client.account.SummonerID, opponentId)
+	if err != nil {
+		client.Log.Debug(err)
+		client.Log.Error("Error while getting opponent information")
+		return nil
+	}
+
+	build, err := matchupProvider.MatchupBuild(champion, opponent, mode.BuildMode, position)
+	if err != nil {
+		client.Log.Debug(err)
+		client.Log.Warning("Matchup data against ", opponent.Alias, " is not available")
+		return nil
+	}
+
+	var data cache.CachedData
+	if err = client.retrieveRunes(build, &data, champion.Alias, "vs "+opponent.Alias); err != nil {
+		client.Log.Debug(err)
+		client.Log.Warning("Matchup rune page against ", opponent.Alias, " is not available")
+	}
+	if err = client.retrieveItems(build, &data, champion.ID, "vs "+opponent.Alias); err != nil {
+		client.Log.Debug(err)
+		client.Log.Warning("Matchup item set against ", opponent.Alias, " is not available")
+	}
+	if len(data.RunePages) == 0 && len(data.ItemPages.ItemSets) == 0 {
+		return nil
+	}
+
+	named := client.applyNames(&data, runePageFields{
+		champion: champion.Alias,
+		role:     position.String(),
+		mode:     "vs " + opponent.Alias,
+	})
+	matchup := &matchupBuild{opponent: opponent.Alias}
+	if len(named.RunePages) > 0 {
+		matchup.page = &named.RunePages[0]
+	}
+	if len(named.ItemPages.ItemSets) > 0 {
+		// Placed after item sets of the build data, so that they are not overwritten
+		itemSet := named.ItemPages.ItemSets[0]
+		itemSet.UID = itemSetUID(champion.ID, len(cachedData.ItemPages.ItemSets))
+		matchup.itemSet = &itemSet
+		matchup.variant = named.ItemVariants[0]
+	}
+	for i := range cachedData.Matchups {
+		if cachedData.Matchups[i].ChampionID == opponentId {
+			matchup.stats = &cachedData.Matchups[i]
+			break
+		}
+	}
+	client.Log.Info("Matchup build against ", opponent.Alias, " is available")
+
+	return matchup
+}
+
+// option returns the idx-th option of the matchup. Win rate of the matchup is shown if available,
+// otherwise the win rate of its build.
+func (matchup *matchupBuild) option(idx int, winRate float64, sampleCnt int) string {
+	if matchup.stats != nil {
+		winRate, sampleCnt = matchup.stats.WinRate, matchup.stats.SampleCnt
+	}
+	return fmt.Sprintf("%d. vs %s WR:%.1f%% Sample: %d", idx+1, matchup.opponent, winRate, sampleCnt)
+}
+
+// keptOption returns the option to select when options are shown again with matchup, and true if it has to be
+// applied. prev is the previously selected option and count is the number of options before the matchup option.
+// The matchup option is always replaced, so the new matchup is applied if the previous one was selected.
+func keptOption(prev int, count int, hasMatchup bool) (selected int, apply bool) {
+	if prev < count {
+		return prev, false
+	}
+	if hasMatchup {
+		return count, true
+	}
+	return 0, true
+}
+
+// showRunePages shows runePages as rune page options, followed by the matchup rune page if it is available.
+// The selected rune page is kept. The shown rune pages are returned, so that options picked later can be applied.
+func (client *DFFClient) showRunePages(observer Observer, runePages []datatype.DFFRunePage,
+	matchup *matchupBuild) []datatype.DFFRunePage {
+	options := make([]string, len(runePages), len(runePages)+1)
+	for x, elem := range runePages {
+		if elem.Name == userRunePageName {
+			options[x] = fmt.Sprintf("%d. %s", x+1, elem.Name)
+		} else {
+			options[x] = fmt.Sprintf("%d. PR:%.1f%% WR:%.1f%% Sample: %d", x+1, elem.PickRate, elem.WinRate, elem.SampleCnt)
+		}
+	}
+
+	count, hasMatchup := len(options), matchup != nil && matchup.page != nil
+	if hasMatchup {
+		runePages = append(runePages[:len(runePages):len(runePages)], *matchup.page)
+		options = append(options, matchup.option(len(options), matchup.page.WinRate, matchup.page.SampleCnt))
+	}
+
+	prev, _ := client.selectedOptions()
+	selected, apply := keptOption(prev, count, hasMatchup)
+	observer.SetRunePages(options, selected, client.choose(&client.runeChoice))
+	if apply && client.Features().EnableRune {
+		client.applyRunePage(observer, runePages, selected)
+	}
+	return runePages
+}
+
+// applyRunePage sets the i-th of runePages shown as options
+func (client *DFFClient) applyRunePage(observer Observer, runePages []datatype.DFFRunePage, i int) {
+	client.setSelectedRunePage(i, &runePages[i].Page)
+	ok, err := client.setRunePage(&runePages[i].Page)
+	if !ok || err != nil {
+		observer.SetStatus("Error. Check log")
+		observer.RequestAttention()
+	}
+}
+
+// showItemSets shows item sets of itemPage as item set options, followed by the matchup item set if it is available.
+// The selected item set is kept. The shown item sets are returned, so that options picked later can be applied.
+func (client *DFFClient) showItemSets(observer Observer, itemPage datatype.ItemPage, variants []datatype.DFFItemVariant,
+	matchup *matchupBuild) datatype.ItemPage {
+	options := make([]string, len(variants), len(variants)+1)
+	for x, elem := range variants {
+		options[x] = fmt.Sprintf("%d. PR:%.1f%% WR:%.1f%% Sample: %d", x+1, elem.PickRate, elem.WinRate, elem.SampleCnt)
+	}
+
+	count, hasMatchup := len(options), matchup != nil && matchup.itemSet != nil
+	if hasMatchup {
+		itemPage.ItemSets = append(itemPage.ItemSets[:len(itemPage.ItemSets):len(itemPage.ItemSets)], *matchup.itemSet)
+		options = append(options, matchup.option(len(options), matchup.variant.WinRate, matchup.variant.SampleCnt))
+	}
+
+	_, prev := client.selectedOptions()
+	selected, apply := keptOption(prev, count, hasMatchup)
+	observer.SetItemSets(options, selected, client.choose(&client.itemChoice))
+	if apply && client.Features().EnableItem {
+		client.applyItemSet(observer, &itemPage, selected)
+	}
+	return itemPage
+}
+
+// applyItemSet sets the i-th item set of itemPage shown as options
+func (client *DFFClient) applyItemSet(observer Observer, itemPage *datatype.ItemPage, i int) {
+	if err := client.setItemSets(itemPage, i); err != nil {
+		client.Log.Debug(err)
+		client.Log.Error("Error while setting items")
+		observer.SetStatus("Error. Check log")
+		observer.RequestAttention()
+		return
+	}
+	client.setSelectedItemSet(i, &itemPage.ItemSets[i])
+}
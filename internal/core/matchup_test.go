@@ -0,0 +1,184 @@
+package core
+
+import (
+	"encoding/json"
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLaneOpponent(t *testing.T) {
+	client, _ := newTestClient(t)
+	client.metaInfo.Existing[238] = &MetaChampion{Positions: []MetaPosition{{Position: cache.Mid}}}
+	client.metaInfo.Existing[64] = &MetaChampion{Positions: []MetaPosition{{Position: cache.Jungle}}}
+
+	tests := []struct {
+		enemies  []enemyPick
+		position cache.Position
+		opponent int
+	}{
+		{enemies: nil, position: cache.Mid, opponent: 0},
+		{enemies: []enemyPick{{champId: 64, assigned: cache.None}, {champId: 238, assigned: cache.None}}, position: cache.Mid, opponent: 238},
+		{enemies: []enemyPick{{champId: 64, assigned: cache.None}, {champId: 238, assigned: cache.None}}, position: cache.Top, opponent: 0},
+		{enemies: []enemyPick{{champId: 64, assigned: cache.None}, {champId: 238, assigned: cache.None}}, position: cache.None, opponent: 0},
+		// Assigned positions are used first
+		{enemies: []enemyPick{{champId: 238, assigned: cache.Top}, {champId: 64, assigned: cache.Mid}}, position: cache.Mid, opponent: 64},
+		{enemies: []enemyPick{{champId: 238, assigned: cache.Top}}, position: cache.Mid, opponent: 0},
+	}
+
+	for i, test := range tests {
+		if opponent := client.laneOpponent(test.enemies, test.position); opponent != test.opponent {
+			t.Error("Incorrect opponent for test ", i, ": ", opponent)
+		}
+	}
+}
+
+func TestRunMatchup(t *testing.T) {
+	client, server := newTestClient(t)
+	client.metaInfo.Existing[238] = &MetaChampion{Positions: []MetaPosition{{Position: cache.Mid}}}
+
+	server.AddChampion(datatype.Champion{ID: 103, Alias: "Ahri"})
+	server.AddChampion(datatype.Champion{ID: 238, Alias: "Zed"})
+	server.SetQueueID(420)
+	server.SetRunePages(nil, 10)
+	server.SetSession(newTestSession(t, 1234, 103))
+
+	observer := &testObserver{}
+	done := make(chan struct{})
+	go func() {
+		client.Run(observer)
+		close(done)
+	}()
+
+	runePages := func() []string {
+		observer.mu.Lock()
+		defer observer.mu.Unlock()
+		return observer.runePages
+	}
+	waitUntil(t, 5*time.Second, func() bool {
+		return len(runePages()) == 1
+	})
+	observer.mu.Lock()
+	onItemSet := observer.onItemSet
+	observer.mu.Unlock()
+	onItemSet(1)
+
+	// Enemy picks Zed after the build data is applied
+	session := newTestSession(t, 1234, 103)
+	if err := json.Unmarshal([]byte(`{"theirTeam": [{"championId": 0}, {"championId": 238}]}`), session); err != nil {
+		t.Fatal(err)
+	}
+	server.SetSession(session)
+	waitUntil(t, 5*time.Second, func() bool {
+		return len(runePages()) == 2
+	})
+	if options := runePages(); options[1] != "2. vs Zed WR:52.0% Sample: 200" {
+		t.Error("Incorrect matchup option: ", options)
+	}
+	waitUntil(t, 5*time.Second, func() bool {
+		observer.mu.Lock()
+		defer observer.mu.Unlock()
+		return len(observer.itemSets) == 3
+	})
+
+	observer.mu.Lock()
+	onRune, onItemSet := observer.onRune, observer.onItemSet
+	itemOptions, itemSelected := observer.itemSets, observer.itemSelected
+	observer.mu.Unlock()
+	if itemOptions[2] != "3. vs Zed WR:52.0% Sample: 200" || itemSelected != 1 {
+		t.Error("Selected item set is not kept with the matchup item set: ", itemOptions, itemSelected)
+	}
+	onRune(1)
+	waitUntil(t, 5*time.Second, func() bool {
+		pages := server.RunePages()
+		return len(pages) == 1 && strings.HasSuffix(pages[0].Name, "vs Zed")
+	})
+	if pages := server.RunePages(); pages[0].SelectedPerkIds[0] != 8128 {
+		t.Error("Matchup rune page is not set: ", pages)
+	}
+	onItemSet(2)
+	waitUntil(t, 5*time.Second, func() bool {
+		itemPage, _ := server.ItemPage(1234)
+		return len(itemPage.ItemSets) == 3 && strings.Contains(itemPage.ItemSets[0].Title, "vs Zed")
+	})
+	if itemPage, _ := server.ItemPage(1234); itemPage.ItemSets[0].Blocks[1].Items[0].ID != "3157" {
+		t.Error("Matchup item set is not set: ", itemPage.ItemSets)
+	}
+
+	server.SetSession(nil)
+	<-done
+}
+
+// Options are picked on the goroutine of the control server or the window while the session goroutine updates them
+func TestSelectDuringMatchup(t *testing.T) {
+	client, server := newTestClient(t)
+	client.metaInfo.Existing[238] = &MetaChampion{Positions: []MetaPosition{{Position: cache.Mid}}}
+
+	server.AddChampion(datatype.Champion{ID: 103, Alias: "Ahri"})
+	server.AddChampion(datatype.Champion{ID: 238, Alias: "Zed"})
+	server.SetQueueID(420)
+	server.SetRunePages(nil, 10)
+	server.SetSession(newTestSession(t, 1234, 103))
+
+	observer := &testObserver{}
+	done := make(chan struct{})
+	go func() {
+		client.Run(observer)
+		close(done)
+	}()
+
+	options := func() (runePages []string, onRune func(int), onItemSet func(int)) {
+		observer.mu.Lock()
+		defer observer.mu.Unlock()
+		return observer.runePages, observer.onRune, observer.onItemSet
+	}
+	waitUntil(t, 5*time.Second, func() bool {
+		runePages, _, _ := options()
+		return len(runePages) == 1
+	})
+
+	// Select like the control server does, from its own goroutine
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			_, onRune, onItemSet := options()
+			onRune(0)
+			onItemSet(i % 2)
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	session := newTestSession(t, 1234, 103)
+	if err := json.Unmarshal([]byte(`{"theirTeam": [{"championId": 0}, {"championId": 238}]}`), session); err != nil {
+		t.Fatal(err)
+	}
+	server.SetSession(session)
+	waitUntil(t, 5*time.Second, func() bool {
+		runePages, _, _ := options()
+		return len(runePages) == 2
+	})
+	close(stop)
+	<-stopped
+
+	// The last choice is applied by the session goroutine
+	_, onRune, _ := options()
+	onRune(1)
+	waitUntil(t, 5*time.Second, func() bool {
+		pages := server.RunePages()
+		return len(pages) == 1 && strings.HasSuffix(pages[0].Name, "vs Zed")
+	})
+	if runeIdx, _ := client.selectedOptions(); runeIdx != 1 {
+		t.Error("Incorrect selected rune page: ", runeIdx)
+	}
+
+	server.SetSession(nil)
+	<-done
+}
@@ -13,6 +13,7 @@ type Meta struct {
 	CreationTime      time.Time
 	CacheVersion      uint16
 	GameClientVersion string // Must be updated once game client API is accessible
+	Bracket           string // region and tier of positions
 	Existing          map[int]*MetaChampion
 }
 
@@ -27,68 +28,112 @@ type MetaPosition struct {
 }
 
 // ChampListDataVersion is used to keep track of cache file versions.
-// If cache structure is edited in any way, this value must be incremented.
-const ChampListDataVersion uint16 = 1
+// If cache structure is edited in any way, this value must be incremented and a migration must be added.
+const ChampListDataVersion uint16 = 2
+
+// oldestChampListVersion is the oldest champion list version which can be migrated to ChampListDataVersion
+const oldestChampListVersion uint16 = 1
+
+// champListMigrations upgrade champion lists decoded from an older version step by step, like cache migrations.
+// champListMigrations[v] upgrades a list of version v to v+1. Every version from oldestChampListVersion to
+// ChampListDataVersion-1 must have a migration.
+var champListMigrations = map[uint16]func(meta *Meta){
+	// Bracket added. Lists without a bracket are outdated, but kept in case a new list cannot be created.
+	1: func(meta *Meta) {},
+}
 
 // ChampListDataExpiration data expiration time in days
 const ChampListDataExpiration = 7
 
 var incompatibleDataError = errors.New("existing data is incompatible")
 var expiredDataError = errors.New("existing data expired")
+var outdatedDataError = errors.New("existing data is for another patch or bracket")
 
 func (client *DFFClient) saveChampionList(filename string) (err error) {
-	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	return gob.NewEncoder(file).Encode(&client.metaInfo)
+	return writeMeta(filename, client.metaInfo)
 }
 
+// restoreChampionList restores the saved champion list. The list is restored even if expiredDataError or
+// outdatedDataError is returned, so that it can be used if a new list cannot be created.
 func (client *DFFClient) restoreChampionList(filename string, gameVer string) (err error) {
-	file, err := os.Open(filename)
-	if err != nil {
+	if client.metaInfo, err = readMeta(filename); err != nil {
+		client.Log.Debug(err)
 		return
 	}
-	defer file.Close()
 
-	if err = gob.NewDecoder(file).Decode(&client.metaInfo); err != nil {
-		client.Log.Debug(err)
-		return
+	if err = client.metaInfo.migrate(); err != nil {
+		client.metaInfo = nil
+		return err
 	}
 
 	if t := time.Now().Sub(client.metaInfo.CreationTime); t >= time.Hour*24*ChampListDataExpiration {
 		return expiredDataError
 	}
 
-	if client.metaInfo.CacheVersion != ChampListDataVersion || client.metaInfo.GameClientVersion != gameVer {
-		return incompatibleDataError
+	if client.metaInfo.GameClientVersion != gameVer || client.metaInfo.Bracket != client.bracket() {
+		return outdatedDataError
 	}
 
 	return
 }
 
-func (client *DFFClient) createChampionList(gameVer string) (ok bool) {
-	url := "https://na.op.gg/champions"
+// migrate upgrades meta to ChampListDataVersion. Returns incompatibleDataError if meta cannot be migrated.
+func (meta *Meta) migrate() error {
+	version := meta.CacheVersion
+	if version < oldestChampListVersion || version > ChampListDataVersion {
+		return incompatibleDataError
+	}
 
-	data, ok := client.getFromJson(url)
-	if !ok {
-		client.Log.Fatal("Could not create champion list")
-		return
+	for ; version < ChampListDataVersion; version++ {
+		champListMigrations[version](meta)
 	}
+	meta.CacheVersion = ChampListDataVersion
 
-	champList := data.Props.PageProps.ChampionMetaList
+	return nil
+}
+
+// writeMeta saves meta to filename
+func writeMeta(filename string, meta *Meta) (err error) {
+	file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return gob.NewEncoder(file).Encode(&meta)
+}
+
+// readMeta reads saved meta without checking compatibility or expiration
+func readMeta(filename string) (meta *Meta, err error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if err = gob.NewDecoder(file).Decode(&meta); err != nil {
+		return nil, err
+	}
+
+	return meta, nil
+}
+
+func (client *DFFClient) createChampionList(gameVer string) (ok bool) {
+	champList, err := client.provider.ChampionList()
+	if err != nil {
+		client.Log.Debug(err)
+		client.Log.Error("Could not create champion list. If roles were changed, please submit a new issue at " + IssueUrl)
+		return false
+	}
 
 	client.metaInfo = &Meta{
 		CreationTime:      time.Now(),
 		CacheVersion:      ChampListDataVersion,
 		GameClientVersion: gameVer,
+		Bracket:           client.bracket(),
 		Existing:          make(map[int]*MetaChampion, len(champList)),
 	}
 
-	var role cache.Position
-
 	for _, champ := range champList {
 		client.metaInfo.Existing[champ.ID] = &MetaChampion{}
 		client.metaInfo.Existing[champ.ID].IsRip = champ.IsRip
@@ -101,24 +146,8 @@ func (client *DFFClient) createChampionList(gameVer string) (ok bool) {
 		} else {
 			client.metaInfo.Existing[champ.ID].Positions = make([]MetaPosition, len(champ.Positions))
 			for i, position := range champ.Positions {
-				switch position.Name {
-				case "TOP":
-					role = cache.Top
-				case "JUNGLE":
-					role = cache.Jungle
-				case "MID":
-					role = cache.Mid
-				case "ADC":
-					role = cache.Adc
-				case "SUPPORT":
-					role = cache.Support
-				default:
-					client.Log.Debug("Role not found: ", position.Name)
-					client.Log.Error("Role changed? Please submit a new issue at " + IssueUrl)
-					return false
-				}
-				client.metaInfo.Existing[champ.ID].Positions[i].Position = role
-				client.metaInfo.Existing[champ.ID].Positions[i].RoleRate = fmt.Sprintf("Pick rate: %.1f%%", position.Stats.RoleRate*100)
+				client.metaInfo.Existing[champ.ID].Positions[i].Position = position.Position
+				client.metaInfo.Existing[champ.ID].Positions[i].RoleRate = fmt.Sprintf("Pick rate: %.1f%%", position.RoleRate*100)
 			}
 		}
 	}
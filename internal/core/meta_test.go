@@ -0,0 +1,80 @@
+package core
+
+import (
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRestoreChampionList(t *testing.T) {
+	client := createDFFClient(ioutil.Discard)
+	filename := filepath.Join(t.TempDir(), championListFileName)
+	meta := &Meta{
+		CreationTime:      time.Now(),
+		CacheVersion:      ChampListDataVersion,
+		GameClientVersion: "12.1.1",
+		Bracket:           client.bracket(),
+		Existing:          map[int]*MetaChampion{103: {}},
+	}
+	if err := writeMeta(filename, meta); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.restoreChampionList(filename, "12.1.1"); err != nil || client.metaInfo.Existing[103] == nil {
+		t.Error("Could not restore champion list: ", err)
+	}
+
+	// The list of a previous patch is kept in case a new list cannot be created
+	if err := client.restoreChampionList(filename, "12.2.1"); err != outdatedDataError || client.metaInfo == nil {
+		t.Error("Champion list of a previous patch should be outdated: ", err)
+	}
+
+	meta.CacheVersion = ChampListDataVersion + 1
+	if err := writeMeta(filename, meta); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.restoreChampionList(filename, "12.1.1"); err != incompatibleDataError || client.metaInfo != nil {
+		t.Error("Champion list of a newer version should not be used: ", err)
+	}
+}
+
+func TestChampListMigrations(t *testing.T) {
+	for version := oldestChampListVersion; version < ChampListDataVersion; version++ {
+		if champListMigrations[version] == nil {
+			t.Error("Missing champion list migration from version ", version)
+		}
+	}
+}
+
+func TestRestoreOldChampionList(t *testing.T) {
+	client := createDFFClient(ioutil.Discard)
+	filename := filepath.Join(t.TempDir(), championListFileName)
+	// Version 1 does not have a bracket
+	meta := &Meta{
+		CreationTime:      time.Now(),
+		CacheVersion:      1,
+		GameClientVersion: "12.1.1",
+		Existing:          map[int]*MetaChampion{103: {Positions: []MetaPosition{{Position: cache.Mid}}}},
+	}
+	if err := writeMeta(filename, meta); err != nil {
+		t.Fatal(err)
+	}
+
+	// The upgraded list is outdated, as its bracket is unknown, but kept in case a new list cannot be created
+	if err := client.restoreChampionList(filename, "12.1.1"); err != outdatedDataError || client.metaInfo == nil {
+		t.Fatal("Champion list of version 1 should be upgraded: ", err)
+	}
+	if client.metaInfo.CacheVersion != ChampListDataVersion || client.metaInfo.Existing[103].Positions[0].Position != cache.Mid {
+		t.Error("Incorrect upgraded champion list: ", client.metaInfo.CacheVersion, client.metaInfo.Existing)
+	}
+
+	meta.CacheVersion = 0
+	if err := writeMeta(filename, meta); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.restoreChampionList(filename, "12.1.1"); err != incompatibleDataError || client.metaInfo != nil {
+		t.Error("Champion list older than the oldest version should not be used: ", err)
+	}
+}
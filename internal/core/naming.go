@@ -0,0 +1,86 @@
+package core
+
+import (
+	"fmt"
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/internal/locale"
+	"strconv"
+	"strings"
+)
+
+// defaultRunePageName is the rune page name template used if none is configured
+const defaultRunePageName = "{champion} ({index}) {mode}"
+
+// english keeps text in English until the configured language is loaded
+var english, _ = locale.Load(locale.English)
+
+// runePageFields are values of placeholders in rune page names which are the same for every page
+type runePageFields struct {
+	champion string
+	role     string // empty for modes without positions
+	mode     string // name of the mode of build data, or the opponent of matchup rune pages
+}
+
+// runePageName returns the name of the index-th rune page from the rune page name template, translated to the
+// configured language. Names always start with ProjectName, so that rune pages created by DFF can be found.
+func (client *DFFClient) runePageName(fields runePageFields, index int, page *datatype.DFFRunePage) string {
+	template := client.RunePageName
+	if template == "" {
+		template = defaultRunePageName
+	}
+
+	// Only the template and known role or mode names are translated, so that champion names are kept
+	name := strings.NewReplacer(
+		"{champion}", fields.champion,
+		"{role}", client.tr.T(fields.role),
+		"{mode}", client.tr.T(fields.mode),
+		"{index}", strconv.Itoa(index+1),
+		"{winrate}", fmt.Sprintf("%.1f%%", page.WinRate),
+		"{pickrate}", fmt.Sprintf("%.1f%%", page.PickRate),
+		"{patch}", client.patch(),
+	).Replace(client.tr.Replace(template))
+
+	// Empty placeholders should not leave extra spaces
+	return strings.Join(append([]string{ProjectName}, strings.Fields(name)...), " ")
+}
+
+// patch returns the patch of the game client, e.g. "12.1" for "12.1.1"
+func (client *DFFClient) patch() string {
+	version := strings.Split(client.gameVersion, ".")
+	if len(version) < 2 {
+		return client.gameVersion
+	}
+	return version[0] + "." + version[1]
+}
+
+// applyNames returns a copy of data with rune pages named by the rune page name template, and
+// item sets translated to the configured language. data is not modified.
+func (client *DFFClient) applyNames(data *cache.CachedData, fields runePageFields) *cache.CachedData {
+	applied := *data
+
+	applied.RunePages = make([]datatype.DFFRunePage, len(data.RunePages))
+	for i, runePage := range data.RunePages {
+		runePage.Page.Name = client.runePageName(fields, i, &runePage)
+		applied.RunePages[i] = runePage
+	}
+
+	applied.ItemPages.ItemSets = make([]datatype.ItemSet, len(data.ItemPages.ItemSets))
+	for i, itemSet := range data.ItemPages.ItemSets {
+		blocks := make([]datatype.ItemBlock, len(itemSet.Blocks))
+		for j, block := range itemSet.Blocks {
+			block.Type = client.tr.Replace(block.Type)
+			blocks[j] = block
+		}
+		itemSet.Blocks = blocks
+		// The prefix is kept, as item sets of older versions are found by the title
+		if strings.HasPrefix(itemSet.Title, itemSetTitlePrefix) {
+			itemSet.Title = itemSetTitlePrefix + client.tr.Replace(strings.TrimPrefix(itemSet.Title, itemSetTitlePrefix))
+		} else {
+			itemSet.Title = client.tr.Replace(itemSet.Title)
+		}
+		applied.ItemPages.ItemSets[i] = itemSet
+	}
+
+	return &applied
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/internal/locale"
+	"io/ioutil"
+	"testing"
+)
+
+func TestRunePageName(t *testing.T) {
+	client := createDFFClient(ioutil.Discard)
+	client.gameVersion = "12.4.1"
+	page := &datatype.DFFRunePage{WinRate: 52.345, PickRate: 40}
+
+	tests := []struct {
+		template string
+		fields   runePageFields
+		name     string
+	}{
+		{template: "", fields: runePageFields{champion: "Ahri"}, name: "DFF! Ahri (2)"},
+		{template: defaultRunePageName, fields: runePageFields{champion: "Ahri", mode: "ARAM"}, name: "DFF! Ahri (2) ARAM"},
+		{template: "{champion} {role} {winrate} {pickrate} {patch}", fields: runePageFields{champion: "Ahri", role: "Mid"},
+			name: "DFF! Ahri Mid 52.3% 40.0% 12.4"},
+		{template: "{role} {champion}", fields: runePageFields{champion: "Ahri"}, name: "DFF! Ahri"},
+	}
+
+	for _, test := range tests {
+		client.RunePageName = test.template
+		if name := client.runePageName(test.fields, 1, page); name != test.name {
+			t.Error("Incorrect name for ", test.template, ": ", name)
+		}
+	}
+}
+
+func TestApplyNames(t *testing.T) {
+	client := createDFFClient(ioutil.Discard)
+	client.tr, _ = locale.Load("ko_KR")
+	client.RunePageName = "{champion} {role}"
+	data := &cache.CachedData{
+		RunePages: []datatype.DFFRunePage{{Page: datatype.RunePage{Name: "DFF! Ahri (1) "}}},
+		ItemPages: datatype.ItemPage{ItemSets: []datatype.ItemSet{{
+			Title:  itemSetTitlePrefix + " (1) WR:52.0% Sample: 200",
+			Blocks: []datatype.ItemBlock{{Type: "Boots"}},
+		}}},
+	}
+
+	applied := client.applyNames(data, runePageFields{champion: "Ahri", role: cache.Mid.String()})
+	if name := applied.RunePages[0].Page.Name; name != "DFF! Ahri 미드" {
+		t.Error("Rune page name is not translated: ", name)
+	}
+	// Champion names are not translated even if they contain translated text
+	sample := client.applyNames(data, runePageFields{champion: "Sample", role: cache.Mid.String()})
+	if name := sample.RunePages[0].Page.Name; name != "DFF! Sample 미드" {
+		t.Error("Champion name is translated: ", name)
+	}
+	itemSet := applied.ItemPages.ItemSets[0]
+	if itemSet.Title != itemSetTitlePrefix+" (1) WR:52.0% 표본: 200" || !isDFFItemSet(&itemSet) {
+		t.Error("Item set title is not translated: ", itemSet.Title)
+	}
+	if blockType := itemSet.Blocks[0].Type; blockType != "신발" {
+		t.Error("Item block is not translated: ", blockType)
+	}
+	if data.RunePages[0].Page.Name != "DFF! Ahri (1) " || data.ItemPages.ItemSets[0].Blocks[0].Type != "Boots" ||
+		data.ItemPages.ItemSets[0].Title != itemSetTitlePrefix+" (1) WR:52.0% Sample: 200" {
+		t.Error("Original data is modified")
+	}
+}
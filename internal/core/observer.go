@@ -0,0 +1,35 @@
+package core
+
+import "github.com/jaeha-choi/DFF/internal/datatype"
+
+// Observer receives status updates from DFFClient and passes choices of the user back to it.
+// Every method may be called from a goroutine other than the one which created the Observer.
+// onSelect callbacks may be called from any goroutine. They only record the choice, which DFFClient applies
+// on its own goroutine, so they return before the League client is updated.
+type Observer interface {
+	// SetStatus is called when the status of DFF changes (e.g. "Waiting...")
+	SetStatus(status string)
+
+	// SetChampion is called when the selected champion changes
+	SetChampion(name string)
+
+	// SetRoles is called with roles available for the selected champion. roles is empty
+	// if the game mode does not have roles. onSelect must be called with the index of
+	// the role the user picked.
+	SetRoles(roles []string, selected int, onSelect func(idx int))
+
+	// SetRunePages is called with rune pages available for the selected champion.
+	// onSelect must be called with the index of the rune page the user picked.
+	SetRunePages(pages []string, selected int, onSelect func(idx int))
+
+	// SetItemSets is called with item sets available for the selected champion.
+	// onSelect must be called with the index of the item set the user picked.
+	SetItemSets(itemSets []string, selected int, onSelect func(idx int))
+
+	// SetSkillOrders is called with skill orders for the selected champion, sorted by preference.
+	// orders is empty if skill orders are not available.
+	SetSkillOrders(orders []datatype.DFFSkillOrder)
+
+	// RequestAttention is called when an error occurred
+	RequestAttention()
+}
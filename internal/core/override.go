@@ -0,0 +1,261 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+)
+
+// OverridesVersion is used to keep track of overrides file versions.
+// If Overrides is edited in any way, this value must be incremented.
+const OverridesVersion uint16 = 1
+
+// OverridesFileName is the name of the user overrides file
+const OverridesFileName = "overrides.json"
+
+// Item block override modes
+const (
+	ItemBlocksReplace = "replace"
+	ItemBlocksAppend  = "append"
+)
+
+// userRunePageName is the name of rune pages pinned by the user
+const userRunePageName = "My default"
+
+var incompatibleOverridesError = errors.New("overrides file is incompatible")
+var noSelectionError = errors.New("no champion is selected")
+
+// Override is a user-defined build of a champion, applied on top of the build data.
+// Every field except the key (ChampionID, Mode, Position) is optional.
+type Override struct {
+	ChampionID int               `json:"champion_id"`
+	Mode       datatype.GameMode `json:"mode"`
+	Position   cache.Position    `json:"position"` // empty if the game mode does not have positions
+
+	RunePage   *datatype.RunePage   `json:"rune_page,omitempty"`
+	ItemBlocks []datatype.ItemBlock `json:"item_blocks,omitempty"`
+	ItemMode   string               `json:"item_mode,omitempty"` // "replace" or "append" (default)
+	Spells     *datatype.Spells     `json:"spells,omitempty"`
+}
+
+// Overrides holds every user override. Methods are safe for concurrent use.
+type Overrides struct {
+	Version          uint16                 `json:"version"`
+	DefaultPositions map[int]cache.Position `json:"default_positions"` // keyed by champion ID
+	Overrides        []*Override            `json:"overrides"`
+
+	mu sync.Mutex
+}
+
+// NewOverrides creates empty overrides
+func NewOverrides() *Overrides {
+	return &Overrides{
+		Version:          OverridesVersion,
+		DefaultPositions: make(map[int]cache.Position),
+		Overrides:        []*Override{},
+	}
+}
+
+// ReadOverrides reads overrides from filename
+func ReadOverrides(filename string) (overrides *Overrides, err error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides = NewOverrides()
+	if err = json.Unmarshal(b, overrides); err != nil {
+		return nil, err
+	}
+
+	if overrides.Version != OverridesVersion {
+		return nil, incompatibleOverridesError
+	}
+
+	if overrides.DefaultPositions == nil {
+		overrides.DefaultPositions = make(map[int]cache.Position)
+	}
+
+	return overrides, nil
+}
+
+// Write writes overrides to filename
+func (o *Overrides) Write(filename string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	b, err := json.MarshalIndent(o, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filename, b, 0644)
+}
+
+// DefaultPosition returns the position the user prefers for champId
+func (o *Overrides) DefaultPosition(champId int) (position cache.Position, ok bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	position, ok = o.DefaultPositions[champId]
+	return position, ok
+}
+
+// SetDefaultPosition sets the position the user prefers for champId
+func (o *Overrides) SetDefaultPosition(champId int, position cache.Position) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.DefaultPositions[champId] = position
+}
+
+// Get returns a copy of the override for the key, or nil if it does not exist
+func (o *Overrides) Get(champId int, mode datatype.GameMode, position cache.Position) *Override {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if override := o.find(champId, mode, position); override != nil {
+		overrideCopy := *override
+		return &overrideCopy
+	}
+	return nil
+}
+
+// SetBuild pins the rune page, item blocks and spells for the key. Existing values are kept for nil arguments.
+// Item blocks replace item blocks of the build data.
+func (o *Overrides) SetBuild(champId int, mode datatype.GameMode, position cache.Position,
+	page *datatype.RunePage, itemBlocks []datatype.ItemBlock, spells *datatype.Spells) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	override := o.find(champId, mode, position)
+	if override == nil {
+		override = &Override{ChampionID: champId, Mode: mode, Position: position}
+		o.Overrides = append(o.Overrides, override)
+	}
+	if page != nil {
+		override.RunePage = page
+	}
+	if itemBlocks != nil {
+		override.ItemBlocks = itemBlocks
+		override.ItemMode = ItemBlocksReplace
+	}
+	if spells != nil {
+		override.Spells = spells
+	}
+}
+
+func (o *Overrides) find(champId int, mode datatype.GameMode, position cache.Position) *Override {
+	for _, override := range o.Overrides {
+		if override.ChampionID == champId && override.Mode == mode && override.Position == position {
+			return override
+		}
+	}
+	return nil
+}
+
+// Apply returns a copy of data with the override applied. data is not modified.
+func (override *Override) Apply(data *cache.CachedData) *cache.CachedData {
+	applied := *data
+
+	if override.RunePage != nil {
+		page := *override.RunePage
+		page.ID = 0
+		page.Current = true
+		page.IsActive = true
+		page.IsDeletable = true
+		page.IsEditable = true
+		if !strings.HasPrefix(page.Name, ProjectName) {
+			// Pages without the prefix are not replaced by DFF
+			page.Name = ProjectName + " " + page.Name
+		}
+		if page.AutoModifiedSelections == nil {
+			page.AutoModifiedSelections = []interface{}{}
+		}
+
+		// Pinned page is placed first, so that it is applied by default
+		applied.RunePages = append([]datatype.DFFRunePage{{Name: userRunePageName, Page: page}}, data.RunePages...)
+	}
+
+	if len(override.ItemBlocks) > 0 {
+		applied.ItemPages.ItemSets = append([]datatype.ItemSet{}, data.ItemPages.ItemSets...)
+		for i := range applied.ItemPages.ItemSets {
+			itemSet := &applied.ItemPages.ItemSets[i]
+			if override.ItemMode == ItemBlocksReplace {
+				itemSet.Blocks = override.ItemBlocks
+			} else {
+				itemSet.Blocks = append(append([]datatype.ItemBlock{}, itemSet.Blocks...), override.ItemBlocks...)
+			}
+		}
+	}
+
+	if override.Spells != nil {
+		applied.Spells = *override.Spells
+	}
+
+	return &applied
+}
+
+// SaveCurrentAsDefault saves the currently selected position, rune page, item set and spells
+// as the user's default for the current champion. Only the build is saved, not where it came from.
+func (client *DFFClient) SaveCurrentAsDefault() error {
+	client.selectionMu.Lock()
+	current := client.selection
+	client.selectionMu.Unlock()
+
+	if current.championID == 0 {
+		return noSelectionError
+	}
+
+	if current.mode == datatype.Default && current.position != cache.None {
+		client.overrides.SetDefaultPosition(current.championID, current.position)
+	}
+
+	var page *datatype.RunePage
+	if current.runePage != nil {
+		// IDs and timestamps of the League client are not reused
+		page = &datatype.RunePage{
+			Name:            current.runePage.Name,
+			PrimaryStyleID:  current.runePage.PrimaryStyleID,
+			SubStyleID:      current.runePage.SubStyleID,
+			SelectedPerkIds: append([]int{}, current.runePage.SelectedPerkIds...),
+		}
+	}
+	var itemBlocks []datatype.ItemBlock
+	if current.itemSet != nil {
+		itemBlocks = append([]datatype.ItemBlock{}, current.itemSet.Blocks...)
+	}
+	var spells *datatype.Spells
+	if current.data != nil {
+		if current.data.Spells.Spell1ID != 0 {
+			spellsCopy := current.data.Spells
+			spells = &spellsCopy
+		}
+	}
+	client.overrides.SetBuild(current.championID, current.mode, current.position, page, itemBlocks, spells)
+
+	if err := client.overrides.Write(OverridesFileName); err != nil {
+		client.Log.Debug(err)
+		client.Log.Error("Error while saving ", OverridesFileName)
+		return err
+	}
+	client.Log.Info("Saved current build as default")
+
+	return nil
+}
+
+// loadOverrides reads user overrides, or creates empty overrides if the file does not exist
+func (client *DFFClient) loadOverrides() {
+	overrides, err := ReadOverrides(OverridesFileName)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			client.Log.Debug(err)
+			client.Log.Error("Error while reading ", OverridesFileName, ". Overrides will not be applied")
+		}
+		overrides = NewOverrides()
+	}
+	client.overrides = overrides
+}
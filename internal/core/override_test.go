@@ -0,0 +1,103 @@
+package core
+
+import (
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOverrideApply(t *testing.T) {
+	data := &cache.CachedData{
+		Spells:    datatype.Spells{Spell1ID: 4, Spell2ID: 14},
+		RunePages: []datatype.DFFRunePage{{Name: "Ahri (1)", Page: datatype.RunePage{Name: ProjectName + " Ahri (1)"}}},
+		ItemPages: datatype.ItemPage{ItemSets: []datatype.ItemSet{{
+			Blocks: []datatype.ItemBlock{{Type: "Starter Items"}},
+		}}},
+	}
+
+	override := &Override{
+		ChampionID: 103,
+		Position:   cache.Mid,
+		RunePage:   &datatype.RunePage{Name: "Electrocute", PrimaryStyleID: 8100},
+		ItemBlocks: []datatype.ItemBlock{{Type: "Situational"}},
+		Spells:     &datatype.Spells{Spell1ID: 4, Spell2ID: 12},
+	}
+
+	applied := override.Apply(data)
+	if len(applied.RunePages) != 2 || applied.RunePages[0].Name != userRunePageName ||
+		applied.RunePages[0].Page.Name != ProjectName+" Electrocute" || applied.RunePages[0].Page.PrimaryStyleID != 8100 {
+		t.Error("Incorrect rune pages: ", applied.RunePages)
+	}
+	if blocks := applied.ItemPages.ItemSets[0].Blocks; len(blocks) != 2 || blocks[1].Type != "Situational" {
+		t.Error("Item blocks are not appended: ", blocks)
+	}
+	if applied.Spells.Spell2ID != 12 {
+		t.Error("Incorrect spells: ", applied.Spells)
+	}
+
+	override.ItemMode = ItemBlocksReplace
+	applied = override.Apply(data)
+	if blocks := applied.ItemPages.ItemSets[0].Blocks; len(blocks) != 1 || blocks[0].Type != "Situational" {
+		t.Error("Item blocks are not replaced: ", blocks)
+	}
+
+	// Original data must not be modified
+	if len(data.RunePages) != 1 || len(data.ItemPages.ItemSets[0].Blocks) != 1 || data.Spells.Spell2ID != 14 {
+		t.Error("Original data is modified")
+	}
+}
+
+func TestSaveCurrentAsDefault(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	if err := client.SaveCurrentAsDefault(); err != noSelectionError {
+		t.Error("Saving without a selection should fail: ", err)
+	}
+
+	data := &cache.CachedData{
+		CreationTime: time.Now(),
+		URL:          "fake/Ahri/Mid",
+		Bracket:      "kr/diamond_plus",
+		Spells:       datatype.Spells{Spell1ID: 4, Spell2ID: 14},
+		ItemPages: datatype.ItemPage{ItemSets: []datatype.ItemSet{
+			{Blocks: []datatype.ItemBlock{{Type: "Core (1)"}}},
+			{Blocks: []datatype.ItemBlock{{Type: "Core (2)"}}},
+		}},
+	}
+	client.setSelection(103, datatype.Default, cache.Mid, &datatype.RunePage{Name: ProjectName + " Ahri (1)"}, data)
+	client.setSelectedRunePage(1, &datatype.RunePage{ID: 55, LastModified: 1234, Name: ProjectName + " Ahri (2)", PrimaryStyleID: 8100})
+	client.setSelectedItemSet(1, &data.ItemPages.ItemSets[1])
+	if err := client.SaveCurrentAsDefault(); err != nil {
+		t.Fatal(err)
+	}
+
+	overrides, err := ReadOverrides(OverridesFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if position, ok := overrides.DefaultPosition(103); !ok || position != cache.Mid {
+		t.Error("Incorrect default position: ", position)
+	}
+	override := overrides.Get(103, datatype.Default, cache.Mid)
+	if override == nil || override.RunePage == nil || !strings.HasSuffix(override.RunePage.Name, "(2)") ||
+		override.RunePage.PrimaryStyleID != 8100 || override.RunePage.ID != 0 || override.RunePage.LastModified != 0 {
+		t.Fatal("Incorrect override: ", override)
+	}
+	if len(override.ItemBlocks) != 1 || override.ItemBlocks[0].Type != "Core (2)" || override.ItemMode != ItemBlocksReplace {
+		t.Error("Selected item set is not saved: ", override.ItemBlocks)
+	}
+	if override.Spells == nil || override.Spells.Spell2ID != 14 {
+		t.Error("Spells are not saved: ", override.Spells)
+	}
+
+	// Build data of a later session keeps its own provenance
+	fresh := &cache.CachedData{URL: "fake/Ahri/Mid/new", ItemPages: data.ItemPages}
+	if applied := override.Apply(fresh); applied.URL != fresh.URL || !applied.CreationTime.IsZero() || applied.Bracket != "" {
+		t.Error("Provenance of the saved build is applied: ", applied.URL, applied.CreationTime, applied.Bracket)
+	}
+	if overrides.Get(103, datatype.Aram, cache.None) != nil {
+		t.Error("Override should not exist for ARAM")
+	}
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/lcu"
+)
+
+// positionSource is where the position of the selected champion comes from
+type positionSource int
+
+const (
+	// positionSelected is picked by the user
+	positionSelected positionSource = iota
+	// positionAssigned is assigned by the League client in draft pick and ranked
+	positionAssigned
+	// positionDefault is saved as the default position of the champion by the user
+	positionDefault
+	// positionMeta is the most played position on op.gg
+	positionMeta
+)
+
+func (s positionSource) String() string {
+	switch s {
+	case positionAssigned:
+		return "Assigned"
+	case positionDefault:
+		return "My default"
+	case positionMeta:
+		return "Most played"
+	default:
+		return ""
+	}
+}
+
+// choosePosition returns the position used when champId is selected. The position assigned in champion
+// select is used first, so that the most played position is only used in blind pick.
+func (client *DFFClient) choosePosition(champId int, assigned cache.Position) (cache.Position, positionSource) {
+	if assigned != cache.None {
+		return assigned, positionAssigned
+	}
+
+	if userPosition, ok := client.overrides.DefaultPosition(champId); ok {
+		return userPosition, positionDefault
+	}
+
+	// "RIP" champions and champions without data use Top as a default position
+	champMeta := client.metaInfo.Existing[champId]
+	if champMeta == nil || champMeta.IsRip || len(champMeta.Positions) == 0 {
+		client.Log.Info("Champion ", champId, " does not have enough sample count.")
+		return cache.Top, positionMeta
+	}
+
+	// Other champions use most frequently used position as a default position
+	return champMeta.Positions[0].Position, positionMeta
+}
+
+// rolePositions returns positions of champId shown as role options. position is added if it is
+// not one of positions on op.gg (e.g. off-role pick in draft).
+func (client *DFFClient) rolePositions(champId int, position cache.Position) []MetaPosition {
+	var positions []MetaPosition
+	if champMeta := client.metaInfo.Existing[champId]; champMeta != nil {
+		positions = champMeta.Positions
+	}
+
+	for _, p := range positions {
+		if p.Position == position {
+			return positions
+		}
+	}
+	if position == cache.None {
+		return positions
+	}
+	return append(append([]MetaPosition{}, positions...), MetaPosition{Position: position, RoleRate: "Not enough sample count"})
+}
+
+// enemyPick is a champion picked by the enemy team
+type enemyPick struct {
+	champId  int
+	assigned cache.Position // cache.None if not visible
+}
+
+// getMyPlayer returns the champion ID and the assigned position of the user in champion select,
+// with champions picked by the enemy team. Position is cache.None if no position is assigned (e.g. blind pick).
+func (client *DFFClient) getMyPlayer() (champId int, assigned cache.Position, enemies []enemyPick, err error) {
+	assigned = cache.None
+
+	champSelect, err := client.api.GetSession()
+	if lcu.IsNotFound(err) {
+		return 0, cache.None, nil, nil
+	} else if err != nil {
+		client.Log.Debug(err)
+		client.Log.Error("Error while getting champion ID")
+		return 0, cache.None, nil, err
+	}
+
+	// Find current user's champion ID
+	for _, member := range champSelect.MyTeam {
+		if member.SummonerID == client.account.SummonerID {
+			champId = member.ChampionID
+			if position, ok := cache.ParseAssignedPosition(member.AssignedPosition); ok {
+				assigned = position
+			}
+			break
+		}
+	}
+
+	// Enemy picks are hidden in blind pick
+	for _, member := range champSelect.TheirTeam {
+		if member.ChampionID == 0 {
+			continue
+		}
+		position, _ := cache.ParseAssignedPosition(member.AssignedPosition)
+		enemies = append(enemies, enemyPick{champId: member.ChampionID, assigned: position})
+	}
+
+	return champId, assigned, enemies, nil
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChoosePosition(t *testing.T) {
+	client, _ := newTestClient(t)
+	client.overrides.SetDefaultPosition(99, cache.Support)
+
+	tests := []struct {
+		champId  int
+		assigned cache.Position
+		position cache.Position
+		source   positionSource
+	}{
+		{champId: 103, assigned: cache.Top, position: cache.Top, source: positionAssigned},
+		{champId: 103, assigned: cache.None, position: cache.Mid, source: positionMeta},
+		{champId: 99, assigned: cache.Jungle, position: cache.Jungle, source: positionAssigned},
+		{champId: 99, assigned: cache.None, position: cache.Support, source: positionDefault},
+		// Champions without data
+		{champId: 1, assigned: cache.None, position: cache.Top, source: positionMeta},
+	}
+
+	for _, test := range tests {
+		if position, source := client.choosePosition(test.champId, test.assigned); position != test.position || source != test.source {
+			t.Error("Incorrect position of ", test.champId, ", ", test.assigned, ": ", position, source)
+		}
+	}
+
+	if positions := client.rolePositions(103, cache.Support); len(positions) != 2 || positions[1].Position != cache.Support {
+		t.Error("Assigned position is not added: ", positions)
+	}
+	if positions := client.rolePositions(103, cache.Mid); len(positions) != 1 {
+		t.Error("Incorrect positions: ", positions)
+	}
+}
+
+func TestRunAssignedPosition(t *testing.T) {
+	client, server := newTestClient(t)
+
+	server.AddChampion(datatype.Champion{ID: 103, Alias: "Ahri"})
+	server.SetQueueID(420)
+	server.SetRunePages(nil, 10)
+	server.SetSession(newDraftSession(t, 1234, 103, "utility"))
+
+	observer := &testObserver{}
+	done := make(chan struct{})
+	go func() {
+		client.Run(observer)
+		close(done)
+	}()
+
+	waitUntil(t, 5*time.Second, func() bool {
+		data, ok := client.CurrentData()
+		return ok && data.URL == "fake/Ahri/Support"
+	})
+	waitUntil(t, 5*time.Second, func() bool {
+		observer.mu.Lock()
+		defer observer.mu.Unlock()
+		return len(observer.roles) == 2
+	})
+
+	observer.mu.Lock()
+	roles := observer.roles
+	observer.mu.Unlock()
+	if !strings.HasPrefix(roles[1], "Support") || !strings.HasSuffix(roles[1], "(Assigned)") || strings.HasSuffix(roles[0], ")") {
+		t.Error("Incorrect roles: ", roles)
+	}
+
+	server.SetSession(nil)
+	<-done
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"github.com/jaeha-choi/DFF/internal/lcu"
+)
+
+// enableRecording records every League client request to RecordDir, if it is set.
+// Recordings can be replayed with lcu.ReplayTransport to reproduce a champion select.
+func (client *DFFClient) enableRecording() {
+	if client.RecordDir == "" {
+		return
+	}
+	client.recorder = lcu.NewRecordingTransport(client.gameClient.Transport)
+	client.gameClient.Transport = client.recorder
+	client.Log.Info("Recording client requests to ", client.RecordDir)
+}
+
+// saveRecording saves requests recorded since the last call to a new file in RecordDir
+func (client *DFFClient) saveRecording() {
+	if client.recorder == nil || client.recorder.Len() == 0 {
+		return
+	}
+
+	filename, err := lcu.WriteRecording(client.RecordDir, client.recorder.Flush())
+	if err != nil {
+		client.Log.Debug(err)
+		client.Log.Error("Error while saving a recording")
+		return
+	}
+	client.Log.Info("Recording saved to ", filename)
+}
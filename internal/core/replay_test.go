@@ -0,0 +1,353 @@
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/internal/lcu"
+	"github.com/jaeha-choi/DFF/internal/lcu/lcutest"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Recordings in testdata/replay are synthetic. They are recorded from lcutest.Server, not a real League client, with:
+// go test ./internal/core -run TestReplay -record
+// Only the champion select exchange is kept. See champSelectExchange.
+var record = flag.Bool("record", false, "record champion select sessions in testdata/replay from the fake League client")
+
+// Requests made while connecting, which are not part of recorded champion select exchanges
+const currentSummonerPath = "/lol-summoner/v1/current-summoner"
+
+// scriptedObserver is a testObserver which calls onUpdate whenever Run finishes updating a champion,
+// before Run makes the next request. n starts at 1.
+type scriptedObserver struct {
+	testObserver
+	updates  int
+	onUpdate func(n int, o *scriptedObserver)
+}
+
+func (o *scriptedObserver) SetRoles(roles []string, selected int, onSelect func(idx int)) {
+	o.testObserver.SetRoles(roles, selected, onSelect)
+	o.updates++
+	if o.onUpdate != nil {
+		o.onUpdate(o.updates, o)
+	}
+}
+
+// replayScenario is a champion select session. server is nil while replaying, as changes made
+// to the League client are in the recording.
+type replayScenario struct {
+	name     string
+	queueId  int
+	prepare  func(client *DFFClient)
+	onUpdate func(t *testing.T, n int, o *scriptedObserver, server *lcutest.Server)
+	check    func(t *testing.T, client *DFFClient, o *scriptedObserver, requests []lcu.Interaction)
+}
+
+var replayScenarios = []replayScenario{
+	{
+		name:    "role_switch",
+		queueId: 420,
+		prepare: func(client *DFFClient) {
+			client.metaInfo.Existing[103].Positions = []MetaPosition{
+				{Position: cache.Mid, RoleRate: "Pick rate: 90.0%"},
+				{Position: cache.Top, RoleRate: "Pick rate: 5.0%"},
+			}
+		},
+		onUpdate: func(t *testing.T, n int, o *scriptedObserver, server *lcutest.Server) {
+			switch n {
+			case 1:
+				o.onRole(1)
+			case 2:
+				if server != nil {
+					server.SetSession(nil)
+				}
+			}
+		},
+		check: func(t *testing.T, client *DFFClient, o *scriptedObserver, requests []lcu.Interaction) {
+			if builds := atomic.LoadInt32(&client.provider.(*fakeProvider).builds); builds != 2 {
+				t.Error("Incorrect number of builds: ", builds)
+			}
+			if pages := createdRunePages(t, requests); len(pages) != 2 {
+				t.Error("Rune page is not set for each role: ", pages)
+			}
+			if _, isCached := client.cache.GetPut(103, datatype.Default, cache.Top); !isCached {
+				t.Error("Build of the switched role is not cached")
+			}
+		},
+	},
+	{
+		name:    "champion_swap",
+		queueId: 420,
+		onUpdate: func(t *testing.T, n int, o *scriptedObserver, server *lcutest.Server) {
+			if server == nil {
+				return
+			}
+			switch n {
+			case 1:
+				server.SetSession(newTestSession(t, 1234, 99))
+			case 2:
+				server.SetSession(nil)
+			}
+		},
+		check: func(t *testing.T, client *DFFClient, o *scriptedObserver, requests []lcu.Interaction) {
+			pages := createdRunePages(t, requests)
+			if len(pages) != 2 || !strings.Contains(pages[0], "Ahri") || !strings.Contains(pages[1], "Lux") {
+				t.Error("Incorrect rune pages: ", pages)
+			}
+			if o.champion != "Lux" {
+				t.Error("Incorrect champion: ", o.champion)
+			}
+		},
+	},
+	{
+		name:    "aram_reroll",
+		queueId: 450,
+		onUpdate: func(t *testing.T, n int, o *scriptedObserver, server *lcutest.Server) {
+			if server == nil {
+				return
+			}
+			switch n {
+			case 1:
+				server.SetSession(newTestSession(t, 1234, 99))
+			case 2:
+				server.SetSession(nil)
+			}
+		},
+		check: func(t *testing.T, client *DFFClient, o *scriptedObserver, requests []lcu.Interaction) {
+			pages := createdRunePages(t, requests)
+			if len(pages) != 2 || !strings.Contains(pages[1], "Lux") || !strings.HasSuffix(pages[1], "ARAM") {
+				t.Error("Incorrect rune pages: ", pages)
+			}
+			if o.roles != nil {
+				t.Error("Roles should not be shown in ARAM: ", o.roles)
+			}
+			if _, isCached := client.cache.GetPut(99, datatype.Aram, cache.None); !isCached {
+				t.Error("Build of the rerolled champion is not cached")
+			}
+		},
+	},
+	{
+		name:    "dodge",
+		queueId: 420,
+		onUpdate: func(t *testing.T, n int, o *scriptedObserver, server *lcutest.Server) {
+			if server == nil {
+				return
+			}
+			switch n {
+			case 1:
+				// Players are put back into the queue after a dodge
+				server.SetPhase("Matchmaking")
+				server.SetSession(nil)
+			case 2:
+				server.SetSession(newTestSession(t, 1234, 103))
+			case 3:
+				server.SetSession(nil)
+			}
+		},
+		check: func(t *testing.T, client *DFFClient, o *scriptedObserver, requests []lcu.Interaction) {
+			if builds := atomic.LoadInt32(&client.provider.(*fakeProvider).builds); builds != 1 {
+				t.Error("Build data is not reused after a dodge: ", builds)
+			}
+			// Build data is applied again in the next champion select
+			if pages := createdRunePages(t, requests); len(pages) != 2 {
+				t.Error("Incorrect rune pages: ", pages)
+			}
+			waiting := 0
+			for _, status := range o.statuses {
+				if status == "Waiting..." {
+					waiting++
+				}
+			}
+			// Waiting for champion select, a champion after each champion select starts, and the next champion select
+			if waiting != 4 {
+				t.Error("Dodge is not shown: ", o.statuses)
+			}
+			if _, ok := client.CurrentData(); ok {
+				t.Error("Selection is not cleared after a dodge")
+			}
+			if len(o.itemSets) == 0 {
+				t.Error("Item sets are not shown before a dodge")
+			}
+		},
+	},
+}
+
+func TestReplay(t *testing.T) {
+	for _, scenario := range replayScenarios {
+		scenario := scenario
+		t.Run(scenario.name, func(t *testing.T) {
+			filename, err := filepath.Abs(filepath.Join("testdata", "replay", scenario.name+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var client *DFFClient
+			var server *lcutest.Server
+			var replay *lcu.ReplayTransport
+			var recorder *lcu.RecordingTransport
+			if *record {
+				client, server = newTestClient(t)
+				server.AddChampion(datatype.Champion{ID: 103, Alias: "Ahri"})
+				server.AddChampion(datatype.Champion{ID: 99, Alias: "Lux"})
+				server.SetQueueID(scenario.queueId)
+				server.SetRunePages(datatype.RunePages{{ID: 1, Name: "My page", IsDeletable: true}}, 2)
+				server.SetSession(newTestSession(t, 1234, 103))
+				recorder = lcu.NewRecordingTransport(client.gameClient.Transport)
+				client.gameClient.Transport = recorder
+			} else {
+				recording, err := lcu.ReadRecording(filename)
+				if err != nil {
+					t.Fatal(err)
+				}
+				replay = lcu.NewReplayTransport(recording)
+				client = newReplayClient(t, replay)
+			}
+			client.metaInfo.Existing[99] = &MetaChampion{Positions: []MetaPosition{{Position: cache.Support, RoleRate: "Pick rate: 60.0%"}}}
+			if scenario.prepare != nil {
+				scenario.prepare(client)
+			}
+
+			observer := &scriptedObserver{onUpdate: func(n int, o *scriptedObserver) {
+				scenario.onUpdate(t, n, o, server)
+			}}
+			done := make(chan struct{})
+			go func() {
+				client.Run(observer)
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(10 * time.Second):
+				t.Fatal("Run did not return after champion select")
+			}
+
+			if last := observer.statuses[len(observer.statuses)-1]; last != "Idle..." {
+				t.Error("Incorrect status: ", last)
+			}
+
+			var requests []lcu.Interaction
+			if *record {
+				recording := recorder.Flush()
+				if err = os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err = champSelectExchange(recording).Write(filename); err != nil {
+					t.Fatal(err)
+				}
+				requests = recording.Interactions
+			} else {
+				requests = replay.Requests()
+				if n := replay.Remaining(); n != 0 {
+					t.Error("Requests differ from the recording, responses left: ", n)
+				}
+			}
+			scenario.check(t, client, observer, requests)
+		})
+	}
+}
+
+// newReplayClient creates DFFClient which sends requests to replay instead of the League client
+func newReplayClient(t *testing.T, replay *lcu.ReplayTransport) *DFFClient {
+	client, server := newTestClient(t)
+	server.Close()
+
+	// The port accepts connections, so that the lockfile is not stale, but closes them.
+	// Subscribing to events fails and DFF polls the recording.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	if err = ioutil.WriteFile(client.ClientDir+"lockfile", []byte("LeagueClient:0:"+port+":replay:https"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	client.gameClient = &http.Client{Transport: &accountTransport{RoundTripper: replay}}
+	return client
+}
+
+// accountTransport answers requests for the current summoner, and sends other requests with RoundTripper
+type accountTransport struct {
+	http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *accountTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" || req.URL.Path != currentSummonerPath {
+		return t.RoundTripper.RoundTrip(req)
+	}
+	body := `{"accountId": 5678, "summonerId": 1234}`
+	return &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// champSelectExchange returns interactions of recording from the start of the champion select, so that fixtures
+// do not depend on how DFF connects or how long it waited for the champion select. Polls repeating the previous
+// request and response are dropped, as their number depends on timing and ReplayTransport repeats the last
+// response of each request anyway.
+func champSelectExchange(recording *lcu.Recording) *lcu.Recording {
+	trimmed := *recording
+	trimmed.Interactions = nil
+
+	for _, interaction := range recording.Interactions {
+		if trimmed.Interactions == nil && (interaction.Path != "/lol-gameflow/v1/gameflow-phase" ||
+			string(interaction.ResponseBody) != `"ChampSelect"`) {
+			continue
+		}
+		if n := len(trimmed.Interactions); n > 0 && interaction.Method == "GET" {
+			prev := trimmed.Interactions[n-1]
+			if prev.Method == interaction.Method && prev.Path == interaction.Path &&
+				prev.StatusCode == interaction.StatusCode && bytes.Equal(prev.ResponseBody, interaction.ResponseBody) {
+				continue
+			}
+		}
+		trimmed.Interactions = append(trimmed.Interactions, interaction)
+	}
+
+	return &trimmed
+}
+
+// createdRunePages returns names of rune pages created in requests
+func createdRunePages(t *testing.T, requests []lcu.Interaction) (names []string) {
+	for _, request := range requests {
+		if request.Method != "POST" || request.Path != "/lol-perks/v1/pages" {
+			continue
+		}
+		var page datatype.RunePage
+		if err := json.Unmarshal(request.RequestBody, &page); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, strings.TrimSpace(page.Name))
+	}
+	return names
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"errors"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"strings"
+)
+
+var noRunePageSlotError = errors.New("no rune page slot available")
+
+// isDFFRunePage returns true if the rune page named name was created by DFF
+func isDFFRunePage(name string) bool {
+	return strings.HasPrefix(name, ProjectName)
+}
+
+// isProtectedRunePage returns true if the user protected the rune page named name
+func (client *DFFClient) isProtectedRunePage(name string) bool {
+	for _, protected := range client.ProtectedRunePages {
+		if strings.EqualFold(strings.TrimSpace(protected), strings.TrimSpace(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// setRunePage sets page in the dedicated DFF rune page slot. If ReuseRunePage is true, the existing
+// DFF page is overwritten. Otherwise, DFF pages are deleted before creating a new page.
+// Pages not created by DFF are deleted only if AllowDeleteUserPage is true and no slot is available.
+func (client *DFFClient) setRunePage(page *datatype.RunePage) (bool, error) {
+	runePages, err := client.api.GetRunePages()
+	if err != nil {
+		client.Log.Debug(err)
+		client.Log.Error("Error while getting rune pages from the client")
+		return false, err
+	}
+
+	runePageCnt, err := client.api.GetRunePageCount()
+	if err != nil {
+		client.Log.Debug(err)
+		client.Log.Error("Error while getting total rune pages count")
+		return false, err
+	}
+	client.Log.Debug("Total Rune pages: ", len(runePages))
+
+	var dffPages, userPages []datatype.RunePage
+	for _, p := range runePages {
+		if !p.IsDeletable || client.isProtectedRunePage(p.Name) {
+			continue
+		}
+		if isDFFRunePage(p.Name) {
+			dffPages = append(dffPages, datatype.RunePage(p))
+		} else {
+			userPages = append(userPages, datatype.RunePage(p))
+		}
+	}
+
+	// Only one slot is used by DFF
+	if client.ReuseRunePage && len(dffPages) > 0 {
+		client.deleteRunePages(dffPages[1:])
+		return client.updateRunePage(dffPages[0].ID, page)
+	}
+	// DFF pages which could not be deleted still occupy slots
+	usedSlots := len(dffPages) - client.deleteRunePages(dffPages)
+	for _, p := range runePages {
+		if p.IsDeletable && (!isDFFRunePage(p.Name) || client.isProtectedRunePage(p.Name)) {
+			usedSlots++
+		}
+	}
+
+	if usedSlots >= runePageCnt.OwnedPageCount {
+		if !client.AllowDeleteUserPage || len(userPages) == 0 {
+			client.Log.Error("All rune page slots are used. Delete a rune page or allow DFF to delete one")
+			return false, noRunePageSlotError
+		}
+		client.Log.Info("Deleting rune page \"", userPages[0].Name, "\" to free a slot")
+		if deleted := client.deleteRunePages(userPages[:1]); deleted == 0 {
+			return false, noRunePageSlotError
+		}
+	}
+
+	if err = client.api.CreateRunePage(page); err != nil {
+		client.Log.Debug(err)
+		client.Log.Error("Error while setting a rune page")
+		return false, nil
+	}
+	client.Log.Debug("Rune page set")
+
+	return true, nil
+}
+
+// updateRunePage overwrites the rune page with runePageId with page, and selects it
+func (client *DFFClient) updateRunePage(runePageId int, page *datatype.RunePage) (bool, error) {
+	updated := *page
+	updated.ID = runePageId
+
+	if err := client.api.UpdateRunePage(&updated); err != nil {
+		client.Log.Debug(err)
+		client.Log.Error("Error while updating a rune page")
+		return false, nil
+	}
+
+	if err := client.api.SetCurrentRunePage(runePageId); err != nil {
+		client.Log.Debug(err)
+		client.Log.Error("Error while selecting a rune page")
+		return false, nil
+	}
+	client.Log.Debug("Rune page updated")
+
+	return true, nil
+}
+
+// deleteRunePages deletes pages and returns the number of deleted pages
+func (client *DFFClient) deleteRunePages(pages []datatype.RunePage) (deleted int) {
+	for _, p := range pages {
+		if err := client.api.DeleteRunePage(p.ID); err != nil {
+			client.Log.Debug(err)
+			client.Log.Error("Error while deleting an old DFF rune page")
+			continue
+		}
+		deleted++
+	}
+	return deleted
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"net/http"
+	"testing"
+)
+
+func TestSetRunePage(t *testing.T) {
+	// userPages creates deletable rune pages named names, with IDs starting from 1
+	userPages := func(names ...string) datatype.RunePages {
+		pages := make(datatype.RunePages, len(names))
+		for i, name := range names {
+			pages[i].ID = i + 1
+			pages[i].Name = name
+			pages[i].IsDeletable = true
+			pages[i].IsEditable = true
+		}
+		return pages
+	}
+
+	tests := []struct {
+		name        string
+		pages       datatype.RunePages
+		owned       int
+		reuse       bool
+		allowDelete bool
+		protected   []string
+		failDelete  bool // deleting the page with ID 1 fails
+		ok          bool
+		expected    []string // names of pages after setting, newest first
+		dffPageId   int      // ID of the DFF page after setting, 0 if a new page is created
+	}{
+		{
+			name:     "free slot",
+			pages:    userPages("Favourite"),
+			owned:    2,
+			ok:       true,
+			expected: []string{ProjectName + " new", "Favourite"},
+		},
+		{
+			name:     "replace DFF page",
+			pages:    userPages(ProjectName+" old", "Favourite"),
+			owned:    2,
+			ok:       true,
+			expected: []string{ProjectName + " new", "Favourite"},
+		},
+		{
+			name:     "full slots",
+			pages:    userPages("Favourite", "Other"),
+			owned:    2,
+			ok:       false,
+			expected: []string{"Favourite", "Other"},
+		},
+		{
+			name:        "full slots, deleting allowed",
+			pages:       userPages("Favourite", "Other"),
+			owned:       2,
+			allowDelete: true,
+			protected:   []string{"favourite"},
+			ok:          true,
+			expected:    []string{ProjectName + " new", "Favourite"},
+		},
+		{
+			name:        "full slots, every page protected",
+			pages:       userPages("Favourite", "Other"),
+			owned:       2,
+			allowDelete: true,
+			protected:   []string{"Favourite", "Other"},
+			ok:          false,
+			expected:    []string{"Favourite", "Other"},
+		},
+		{
+			name:      "protected DFF page",
+			pages:     userPages(ProjectName+" keep", "Other"),
+			owned:     2,
+			protected: []string{ProjectName + " keep"},
+			ok:        false,
+			expected:  []string{ProjectName + " keep", "Other"},
+		},
+		{
+			name:       "DFF page not deleted",
+			pages:      userPages(ProjectName+" old", "Favourite"),
+			owned:      2,
+			failDelete: true,
+			ok:         false,
+			expected:   []string{ProjectName + " old", "Favourite"},
+		},
+		{
+			name:      "reuse DFF page",
+			pages:     userPages("Favourite", ProjectName+" old", ProjectName+" older"),
+			owned:     3,
+			reuse:     true,
+			ok:        true,
+			expected:  []string{"Favourite", ProjectName + " new"},
+			dffPageId: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client, server := newTestClient(t)
+			client.connect()
+			client.ReuseRunePage = test.reuse
+			client.AllowDeleteUserPage = test.allowDelete
+			client.ProtectedRunePages = test.protected
+			server.SetRunePages(test.pages, test.owned)
+			if test.failDelete {
+				server.SetFailure("DELETE", "/lol-perks/v1/pages/1", http.StatusInternalServerError)
+			}
+
+			ok, err := client.setRunePage(&datatype.RunePage{Name: ProjectName + " new", Current: true})
+			if ok != test.ok || (err == nil) != test.ok {
+				t.Error("Incorrect result: ", ok, ", ", err)
+			}
+
+			pages := server.RunePages()
+			if len(pages) != len(test.expected) {
+				t.Fatal("Incorrect rune pages: ", pages)
+			}
+			for i, name := range test.expected {
+				if pages[i].Name != name {
+					t.Error("Incorrect rune pages: ", pages)
+				}
+				if name == ProjectName+" new" {
+					if test.dffPageId != 0 && pages[i].ID != test.dffPageId {
+						t.Error("DFF page is not reused: ", pages[i].ID)
+					}
+					if !pages[i].Current {
+						t.Error("DFF page is not selected")
+					}
+				}
+			}
+		})
+	}
+}
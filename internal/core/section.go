@@ -0,0 +1,78 @@
+package core
+
+import (
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/internal/provider"
+	"strings"
+)
+
+// Sections of build data. Each section is converted and applied independently,
+// so that a problem with one section does not prevent others from being applied.
+const (
+	runesSection  = "Runes"
+	itemsSection  = "Items"
+	spellsSection = "Spells"
+)
+
+// sectionError describes why a section of build data could not be used
+type sectionError struct {
+	section string
+	reason  string
+}
+
+// Error implements the error interface.
+func (e *sectionError) Error() string {
+	return e.section + ": " + e.reason
+}
+
+func newSectionError(section string, reason string) error {
+	return &sectionError{section: section, reason: reason}
+}
+
+// convertBuild converts data to cachedData. Sections which could not be converted are returned.
+// cachedData is shared by every mode using the build data of mode.BuildMode (e.g. Spellbook uses build data of
+// normal games without setting spells), so sections are checked for that mode. See usedProblems.
+func (client *DFFClient) convertBuild(mode *datatype.ModeInfo, data *provider.Build, cachedData *cache.CachedData,
+	champion *datatype.Champion, gameType string) (problems []error) {
+	buildMode := datatype.LookupMode(mode.BuildMode)
+
+	if err := client.retrieveRunes(data, cachedData, champion.Alias, gameType); err != nil && buildMode.SetRunes {
+		problems = append(problems, err)
+	}
+	if err := client.retrieveItems(data, cachedData, champion.ID, gameType); err != nil {
+		problems = append(problems, err)
+	}
+	if err := client.retrieveSpells(data, cachedData); err != nil && buildMode.SetSpells {
+		problems = append(problems, err)
+	}
+	client.retrieveSkills(data, cachedData)
+	client.retrieveMatchups(data, cachedData)
+
+	return problems
+}
+
+// usedProblems returns problems with sections which mode sets
+func usedProblems(mode *datatype.ModeInfo, problems []error) (used []error) {
+	for _, problem := range problems {
+		if err, ok := problem.(*sectionError); ok &&
+			(err.section == runesSection && !mode.SetRunes || err.section == spellsSection && !mode.SetSpells) {
+			continue
+		}
+		used = append(used, problem)
+	}
+	return used
+}
+
+// statusText returns the status shown after build data is applied with problems
+func statusText(problems []error) string {
+	if len(problems) == 0 {
+		return "Updated..."
+	}
+
+	reasons := make([]string, len(problems))
+	for i, problem := range problems {
+		reasons[i] = problem.Error()
+	}
+	return "Partially updated. " + strings.Join(reasons, "; ")
+}
@@ -0,0 +1,115 @@
+package core
+
+import (
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/internal/provider"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+// partialProvider returns builds of fakeProvider without valid runes
+type partialProvider struct {
+	fakeProvider
+}
+
+func (p *partialProvider) Build(champion *datatype.Champion, mode datatype.GameMode, position cache.Position) (*provider.Build, error) {
+	build, err := p.fakeProvider.Build(champion, mode, position)
+	if err != nil {
+		return nil, err
+	}
+	build.RunePages[0].StatModIds = nil
+	return build, nil
+}
+
+func TestConvertBuildMalformed(t *testing.T) {
+	client := createDFFClient(ioutil.Discard)
+	client.account = &datatype.AccountInfo{AccountID: 5678}
+	champion := &datatype.Champion{ID: 103, Alias: "Ahri"}
+
+	build := &provider.Build{
+		RunePages: []provider.RuneOption{
+			{PrimaryStyleID: 8100, SubStyleID: 8300, PrimaryRuneIds: []int{8112, 8139, 8138}},
+		},
+		CoreItems:      []provider.ItemOption{{Ids: []int{6655}}},
+		Boots:          []provider.ItemOption{{Ids: nil}, {Ids: []int{3020}}},
+		SummonerSpells: []provider.SpellOption{{Ids: []int{4}}},
+		SkillMasteries: []provider.SkillMastery{{Ids: []string{"Q"}}},
+	}
+
+	data := &cache.CachedData{}
+	problems := client.convertBuild(datatype.LookupMode(datatype.Default), build, data, champion, "")
+	if len(problems) != 2 || problems[0].Error() != "Runes: no valid rune page" || problems[1].Error() != "Spells: no summoner spell data" {
+		t.Error("Incorrect problems: ", problems)
+	}
+	if len(data.ItemPages.ItemSets) != 1 || len(data.ItemPages.ItemSets[0].Blocks) != 3 {
+		t.Error("Items are not converted: ", data.ItemPages)
+	}
+	if len(data.SkillOrders) != 0 {
+		t.Error("Incorrect skill orders: ", data.SkillOrders)
+	}
+
+	// Arena does not use runes and spells
+	problems = client.convertBuild(datatype.LookupMode(datatype.Arena), build, &cache.CachedData{}, champion, "")
+	if len(problems) != 0 {
+		t.Error("Unused sections should not be reported: ", problems)
+	}
+
+	// Spellbook does not set spells, but shares build data with normal games which do
+	spellbook := datatype.LookupMode(datatype.UltimateSpellbook)
+	if problems = client.convertBuild(spellbook, build, &cache.CachedData{}, champion, ""); len(problems) != 2 {
+		t.Error("Sections used by modes sharing build data should be reported: ", problems)
+	}
+	if used := usedProblems(spellbook, problems); len(used) != 1 || used[0].Error() != "Runes: no valid rune page" {
+		t.Error("Incorrect problems for Spellbook: ", used)
+	}
+
+	if problems = client.convertBuild(datatype.LookupMode(datatype.Default), &provider.Build{}, &cache.CachedData{}, champion, ""); len(problems) != 3 {
+		t.Error("Incorrect problems for empty build data: ", problems)
+	}
+
+	if status := statusText(nil); status != "Updated..." {
+		t.Error("Incorrect status: ", status)
+	}
+}
+
+func TestRunPartialBuild(t *testing.T) {
+	client, server := newTestClient(t)
+	client.provider = &partialProvider{}
+
+	server.AddChampion(datatype.Champion{ID: 103, Alias: "Ahri"})
+	server.SetQueueID(420)
+	server.SetRunePages(nil, 10)
+	server.SetSession(newTestSession(t, 1234, 103))
+
+	observer := &testObserver{}
+	done := make(chan struct{})
+	go func() {
+		client.Run(observer)
+		close(done)
+	}()
+
+	waitUntil(t, 5*time.Second, func() bool {
+		_, ok := server.ItemPage(1234)
+		return ok && server.Selection().Spell1ID != 0
+	})
+	server.SetSession(nil)
+	<-done
+
+	found := false
+	for _, status := range observer.statuses {
+		if status == "Partially updated. Runes: no valid rune page" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Problems are not reported: ", observer.statuses)
+	}
+	if pages := server.RunePages(); len(pages) != 0 {
+		t.Error("Invalid rune page is set: ", pages)
+	}
+	if _, isCached := client.cache.GetPut(103, datatype.Default, cache.Mid); isCached {
+		t.Error("Partial build data should not be cached")
+	}
+}
@@ -4,10 +4,16 @@ import "time"
 
 type GameMode int
 
+// GameMode values are the queue IDs of a representative queue of each mode.
+// See Modes for every queue of each mode.
 const (
-	Default GameMode = 0
-	Aram    GameMode = 450
-	Urf     GameMode = 900
+	Default           GameMode = 0
+	Aram              GameMode = 450
+	Urf               GameMode = 900
+	OneForAll         GameMode = 1020
+	NexusBlitz        GameMode = 1300
+	UltimateSpellbook GameMode = 1400
+	Arena             GameMode = 1700
 )
 
 type Spells struct {
@@ -18,11 +24,32 @@ type Spells struct {
 }
 
 type DFFRunePage struct {
-	Name      string
-	PickRate  float64
-	WinRate   float64
-	SampleCnt int
-	Page      RunePage
+	Name      string   `json:"name"`
+	PickRate  float64  `json:"pick_rate"`
+	WinRate   float64  `json:"win_rate"`
+	SampleCnt int      `json:"sample_count"`
+	Page      RunePage `json:"page"`
+}
+
+type DFFItemVariant struct {
+	PickRate  float64 `json:"pick_rate"`
+	WinRate   float64 `json:"win_rate"`
+	SampleCnt int     `json:"sample_count"`
+}
+
+// DFFMatchup is the win rate of a champion against an opponent
+type DFFMatchup struct {
+	ChampionID int     `json:"champion_id"`
+	WinRate    float64 `json:"win_rate"`
+	SampleCnt  int     `json:"sample_count"`
+}
+
+type DFFSkillOrder struct {
+	PickRate  float64  `json:"pick_rate"`
+	WinRate   float64  `json:"win_rate"`
+	SampleCnt int      `json:"sample_count"`
+	Mastery   []string `json:"mastery"` // order of skills to max (e.g. Q, E, W)
+	Order     []string `json:"order"`   // skill to level up at each level
 }
 
 type RunePageCount struct {
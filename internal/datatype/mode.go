@@ -0,0 +1,129 @@
+package datatype
+
+// Map IDs used by item sets
+const (
+	SummonersRiftMap = 11
+	HowlingAbyssMap  = 12
+	NexusBlitzMap    = 21
+	ArenaMap         = 30
+)
+
+// ModeInfo describes how DFF handles a game mode
+type ModeInfo struct {
+	Mode     GameMode
+	Name     string // used in rune/item page titles, empty for Default
+	QueueIDs []int
+
+	// BuildMode is the mode whose build data (and cache slot) is used.
+	// Modes without dedicated build data use Default builds.
+	BuildMode GameMode
+	// DataPath is the op.gg path of build pages (e.g. "modes/aram").
+	// Empty if build data is per position.
+	DataPath string
+	// MapIDs are the maps associated with item sets
+	MapIDs []int
+
+	SetRunes  bool // false if the mode does not use rune pages
+	SetSpells bool // false if summoner spells are fixed by the mode
+}
+
+// HasPositions returns true if build data of the mode depends on the position
+func (m *ModeInfo) HasPositions() bool {
+	return m.BuildMode == Default
+}
+
+// Modes is the registry of known game modes. The first entry is used for unknown queues.
+var Modes = []*ModeInfo{
+	{
+		Mode: Default,
+		// Draft, Ranked Solo/Duo, Blind, Ranked Flex, Quickplay, Clash
+		QueueIDs:  []int{400, 420, 430, 440, 490, 700},
+		BuildMode: Default,
+		MapIDs:    []int{SummonersRiftMap},
+		SetRunes:  true,
+		SetSpells: true,
+	},
+	{
+		Mode: Aram,
+		Name: "ARAM",
+		// ARAM, Butcher's Bridge, ARAM Clash
+		QueueIDs:  []int{450, 100, 720},
+		BuildMode: Aram,
+		DataPath:  "modes/aram",
+		MapIDs:    []int{HowlingAbyssMap},
+		SetRunes:  true,
+		SetSpells: true,
+	},
+	{
+		Mode: Urf,
+		Name: "URF",
+		// ARURF, Snow ARURF, Pick URF, legacy URF
+		QueueIDs:  []int{900, 1010, 1900, 76},
+		BuildMode: Urf,
+		DataPath:  "modes/urf",
+		MapIDs:    []int{SummonersRiftMap},
+		SetRunes:  true,
+		SetSpells: true,
+	},
+	{
+		Mode:      OneForAll,
+		Name:      "OFA",
+		QueueIDs:  []int{1020, 70},
+		BuildMode: Default,
+		MapIDs:    []int{SummonersRiftMap},
+		SetRunes:  true,
+		SetSpells: true,
+	},
+	{
+		Mode:      NexusBlitz,
+		Name:      "Nexus Blitz",
+		QueueIDs:  []int{1300, 1200},
+		BuildMode: Default,
+		MapIDs:    []int{NexusBlitzMap},
+		SetRunes:  true,
+		SetSpells: true,
+	},
+	{
+		Mode:      UltimateSpellbook,
+		Name:      "Spellbook",
+		QueueIDs:  []int{1400},
+		BuildMode: Default,
+		MapIDs:    []int{SummonersRiftMap},
+		SetRunes:  true,
+		// One summoner spell slot is replaced with an ultimate
+		SetSpells: false,
+	},
+	{
+		Mode:      Arena,
+		Name:      "Arena",
+		QueueIDs:  []int{1700, 1710},
+		BuildMode: Arena,
+		DataPath:  "modes/arena",
+		MapIDs:    []int{ArenaMap},
+		// Augments are used instead of runes, and Flash is fixed
+		SetRunes:  false,
+		SetSpells: false,
+	},
+}
+
+// ModeOfQueue returns the mode of queueId. Default is returned for unknown queues.
+func ModeOfQueue(queueId int) *ModeInfo {
+	for _, info := range Modes {
+		for _, id := range info.QueueIDs {
+			if id == queueId {
+				return info
+			}
+		}
+	}
+	return Modes[0]
+}
+
+// LookupMode returns the ModeInfo of mode, or nil if mode is unknown
+func LookupMode(mode GameMode) *ModeInfo {
+	for _, info := range Modes {
+		if info.Mode == mode {
+			return info
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package datatype
+
+import "testing"
+
+func TestModeOfQueue(t *testing.T) {
+	tests := []struct {
+		queueId int
+		mode    GameMode
+	}{
+		{420, Default},
+		{-1, Default},
+		{12345, Default},
+		{450, Aram},
+		{900, Urf},
+		{1900, Urf},
+		{1020, OneForAll},
+		{1300, NexusBlitz},
+		{1400, UltimateSpellbook},
+		{1700, Arena},
+	}
+
+	for _, test := range tests {
+		if mode := ModeOfQueue(test.queueId).Mode; mode != test.mode {
+			t.Error("Incorrect mode for queue ", test.queueId, ": ", mode)
+		}
+	}
+}
+
+func TestModes(t *testing.T) {
+	queues := make(map[int]GameMode)
+	for _, info := range Modes {
+		if build := LookupMode(info.BuildMode); build == nil || build.BuildMode != info.BuildMode {
+			t.Error("Build mode of ", info.Mode, " must have its own build data")
+		}
+		for _, id := range info.QueueIDs {
+			if mode, exist := queues[id]; exist {
+				t.Error("Queue ", id, " is used by both ", mode, " and ", info.Mode)
+			}
+			queues[id] = info.Mode
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Package gui provides the Fyne user interface of DFF
+package gui
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/widget"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/internal/locale"
+)
+
+// Observer implements core.Observer with Fyne widgets
+type Observer struct {
+	window     fyne.Window
+	status     *widget.Label
+	champion   *widget.Label
+	roleSelect *widget.Select
+	runeSelect *widget.Select
+	itemSelect *widget.Select
+
+	skillSelect *widget.Select
+	skillGrid   *widget.Label
+
+	tr *locale.Translator
+}
+
+// NewObserver creates an Observer which displays updates on the given widgets, translated by tr.
+// skillGrid should use a monospace font.
+func NewObserver(window fyne.Window, tr *locale.Translator, status *widget.Label, champion *widget.Label,
+	roleSelect *widget.Select, runeSelect *widget.Select, itemSelect *widget.Select,
+	skillSelect *widget.Select, skillGrid *widget.Label) *Observer {
+	return &Observer{
+		window:      window,
+		status:      status,
+		champion:    champion,
+		roleSelect:  roleSelect,
+		runeSelect:  runeSelect,
+		itemSelect:  itemSelect,
+		skillSelect: skillSelect,
+		skillGrid:   skillGrid,
+		tr:          tr,
+	}
+}
+
+// SetStatus implements core.Observer
+func (o *Observer) SetStatus(status string) {
+	o.status.SetText(o.tr.Replace(status))
+}
+
+// SetChampion implements core.Observer
+func (o *Observer) SetChampion(name string) {
+	o.champion.SetText(o.tr.T(name))
+}
+
+// SetRoles implements core.Observer
+func (o *Observer) SetRoles(roles []string, selected int, onSelect func(idx int)) {
+	if len(roles) == 0 {
+		o.roleSelect.PlaceHolder = o.tr.T("No alternative role available.")
+	}
+	setOptions(o.roleSelect, o.tr.ReplaceAll(roles), selected, onSelect)
+}
+
+// SetRunePages implements core.Observer
+func (o *Observer) SetRunePages(pages []string, selected int, onSelect func(idx int)) {
+	setOptions(o.runeSelect, o.tr.ReplaceAll(pages), selected, onSelect)
+}
+
+// SetItemSets implements core.Observer
+func (o *Observer) SetItemSets(itemSets []string, selected int, onSelect func(idx int)) {
+	setOptions(o.itemSelect, o.tr.ReplaceAll(itemSets), selected, onSelect)
+}
+
+// SetSkillOrders implements core.Observer. Picking a skill order only changes the grid.
+func (o *Observer) SetSkillOrders(orders []datatype.DFFSkillOrder) {
+	if len(orders) == 0 {
+		setOptions(o.skillSelect, nil, -1, nil)
+		o.skillGrid.SetText(SkillGrid(nil))
+		return
+	}
+
+	setOptions(o.skillSelect, skillOrderOptions(orders), 0, func(i int) {
+		o.skillGrid.SetText(SkillGrid(orders[i].Order))
+	})
+	o.skillGrid.SetText(SkillGrid(orders[0].Order))
+}
+
+// RequestAttention implements core.Observer
+func (o *Observer) RequestAttention() {
+	o.window.RequestFocus()
+}
+
+// setOptions replaces options of s without triggering OnChanged
+func setOptions(s *widget.Select, options []string, selected int, onSelect func(idx int)) {
+	s.OnChanged = nil
+	s.Options = options
+	if selected >= 0 && selected < len(options) {
+		s.Selected = options[selected]
+	} else {
+		s.Selected = ""
+	}
+	if onSelect != nil {
+		s.OnChanged = func(str string) {
+			for i, option := range s.Options {
+				if option == str {
+					onSelect(i)
+					return
+				}
+			}
+		}
+	}
+	s.Refresh()
+}
@@ -0,0 +1,43 @@
+package gui
+
+import (
+	"fmt"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"strings"
+)
+
+// skillKeys are rows of the skill grid
+var skillKeys = []string{"Q", "W", "E", "R"}
+
+// SkillGrid formats order as a level-by-level grid, one row per skill.
+// The grid is meant to be displayed with a monospace font.
+func SkillGrid(order []string) string {
+	var b strings.Builder
+
+	b.WriteString("Lv")
+	for level := 1; level <= len(order); level++ {
+		b.WriteString(fmt.Sprintf("%3d", level))
+	}
+
+	for _, key := range skillKeys {
+		b.WriteString("\n" + key + " ")
+		for _, skill := range order {
+			if skill == key {
+				b.WriteString("  " + key)
+			} else {
+				b.WriteString("  .")
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// skillOrderOptions returns options of the skill order selector
+func skillOrderOptions(orders []datatype.DFFSkillOrder) []string {
+	options := make([]string, len(orders))
+	for i, order := range orders {
+		options[i] = fmt.Sprintf("%d. %s WR:%.1f%% Sample: %d", i+1, strings.Join(order.Mastery, ">"), order.WinRate, order.SampleCnt)
+	}
+	return options
+}
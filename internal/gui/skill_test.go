@@ -0,0 +1,15 @@
+package gui
+
+import "testing"
+
+func TestSkillGrid(t *testing.T) {
+	expected := "Lv  1  2  3  4\n" +
+		"Q   Q  .  .  Q\n" +
+		"W   .  W  .  .\n" +
+		"E   .  .  E  .\n" +
+		"R   .  .  .  ."
+
+	if grid := SkillGrid([]string{"Q", "W", "E", "Q"}); grid != expected {
+		t.Error("Incorrect skill grid:\n", grid)
+	}
+}
@@ -0,0 +1,42 @@
+package gui
+
+import (
+	_ "embed"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+)
+
+// hangulFont is Noto Sans CJK KR with only the Hangul glyphs, converted to TrueType for fyne.
+// Other characters are drawn with the default font. See font/LICENSE.txt.
+//
+//go:embed font/NotoSansCJKKR-Hangul.ttf
+var hangulFont []byte
+
+// fontTheme is the default theme with a custom font for regular text.
+// Monospace text keeps the default font, as the skill grid relies on fixed-width characters.
+type fontTheme struct {
+	fyne.Theme
+	font fyne.Resource
+}
+
+// NewFontTheme returns the default theme which uses the TTF font at fontPath,
+// or the embedded Hangul font if fontPath is empty
+func NewFontTheme(fontPath string) (fyne.Theme, error) {
+	var font fyne.Resource = fyne.NewStaticResource("NotoSansCJKKR-Hangul.ttf", hangulFont)
+	if fontPath != "" {
+		var err error
+		if font, err = fyne.LoadResourceFromPath(fontPath); err != nil {
+			return nil, err
+		}
+	}
+	return &fontTheme{Theme: theme.DefaultTheme(), font: font}, nil
+}
+
+// Font implements fyne.Theme
+func (t *fontTheme) Font(style fyne.TextStyle) fyne.Resource {
+	if style.Monospace {
+		return t.Theme.Font(style)
+	}
+	return t.font
+}
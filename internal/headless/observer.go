@@ -0,0 +1,187 @@
+// Package headless provides a user interface for running DFF without a window
+package headless
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/pkg/log"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Observer implements core.Observer by logging updates. Roles and rune pages can be picked
+// by writing commands to the reader passed to Listen.
+type Observer struct {
+	log *log.Logger
+
+	mu       sync.Mutex
+	status   string
+	champion string
+	roles    []string
+	onRole   func(int)
+	pages    []string
+	onRune   func(int)
+	items    []string
+	onItem   func(int)
+	onSave   func()
+}
+
+// NewObserver creates an Observer which logs updates to logger
+func NewObserver(logger *log.Logger) *Observer {
+	return &Observer{log: logger}
+}
+
+// SetStatus implements core.Observer. Only status transitions are logged.
+func (o *Observer) SetStatus(status string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.status != status {
+		o.log.Info("Status: ", status)
+		o.status = status
+	}
+}
+
+// SetChampion implements core.Observer
+func (o *Observer) SetChampion(name string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.champion != name {
+		o.log.Info("Champion: ", name)
+		o.champion = name
+	}
+}
+
+// SetRoles implements core.Observer
+func (o *Observer) SetRoles(roles []string, selected int, onSelect func(idx int)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.roles = roles
+	o.onRole = onSelect
+	if len(roles) > 0 {
+		o.log.Info("Available roles (type \"role <number>\" to change):")
+		logOptions(o.log, roles, selected)
+	}
+}
+
+// SetRunePages implements core.Observer
+func (o *Observer) SetRunePages(pages []string, selected int, onSelect func(idx int)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.pages = pages
+	o.onRune = onSelect
+	if len(pages) > 0 {
+		o.log.Info("Available rune pages (type \"rune <number>\" to change):")
+		logOptions(o.log, pages, selected)
+	}
+}
+
+// SetItemSets implements core.Observer
+func (o *Observer) SetItemSets(itemSets []string, selected int, onSelect func(idx int)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.items = itemSets
+	o.onItem = onSelect
+	if len(itemSets) > 0 {
+		o.log.Info("Available item sets (type \"item <number>\" to change):")
+		logOptions(o.log, itemSets, selected)
+	}
+}
+
+// SetSkillOrders implements core.Observer
+func (o *Observer) SetSkillOrders(orders []datatype.DFFSkillOrder) {
+	if len(orders) == 0 {
+		return
+	}
+	o.log.Info("Skill orders:")
+	for i, order := range orders {
+		o.log.Info(fmt.Sprintf("  %d) %s (WR:%.1f%% Sample: %d)", i+1, strings.Join(order.Order, " "), order.WinRate, order.SampleCnt))
+	}
+}
+
+// SetSaveHandler sets the function called by the "save" command
+func (o *Observer) SetSaveHandler(onSave func()) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.onSave = onSave
+}
+
+// RequestAttention implements core.Observer
+func (o *Observer) RequestAttention() {
+	o.log.Warning("An error occurred. Check log for details")
+}
+
+// Listen reads commands from r line by line until r is closed. Supported commands are:
+//
+//	status       prints the current status
+//	role <n>     picks nth role
+//	rune <n>     picks nth rune page
+//	item <n>     picks nth item set
+//	save         saves the selected role, rune page, item set and spells as the default
+func (o *Observer) Listen(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "status":
+			o.mu.Lock()
+			o.log.Info("Status: ", o.status, ", Champion: ", o.champion)
+			o.mu.Unlock()
+		case "role":
+			o.choose(fields, &o.roles, &o.onRole)
+		case "rune":
+			o.choose(fields, &o.pages, &o.onRune)
+		case "item":
+			o.choose(fields, &o.items, &o.onItem)
+		case "save":
+			o.mu.Lock()
+			onSave := o.onSave
+			o.mu.Unlock()
+			if onSave != nil {
+				onSave()
+			}
+		default:
+			o.log.Warning("Unknown command: ", fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		o.log.Debug(err)
+	}
+}
+
+// choose calls onSelect with the index given by fields[1], which starts from 1
+func (o *Observer) choose(fields []string, options *[]string, onSelect *func(int)) {
+	if len(fields) != 2 {
+		o.log.Warning("Usage: ", fields[0], " <number>")
+		return
+	}
+
+	o.mu.Lock()
+	n, err := strconv.Atoi(fields[1])
+	if err != nil || n < 1 || n > len(*options) || *onSelect == nil {
+		o.mu.Unlock()
+		o.log.Warning("Invalid ", fields[0], ": ", fields[1])
+		return
+	}
+	callback := *onSelect
+	o.log.Info("Selected ", (*options)[n-1])
+	o.mu.Unlock()
+
+	callback(n - 1)
+}
+
+func logOptions(logger *log.Logger, options []string, selected int) {
+	for i, option := range options {
+		if i == selected {
+			logger.Info("* ", i+1, ") ", option)
+		} else {
+			logger.Info("  ", i+1, ") ", option)
+		}
+	}
+}
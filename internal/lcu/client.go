@@ -0,0 +1,216 @@
+package lcu
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// StatusError is returned if the League client responds with an unexpected status code
+type StatusError struct {
+	Method     string
+	Endpoint   string
+	StatusCode int
+	Message    string
+}
+
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s %s: unexpected status code %d", e.Method, e.Endpoint, e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s: unexpected status code %d: %s", e.Method, e.Endpoint, e.StatusCode, e.Message)
+}
+
+// IsNotFound returns true if err is a StatusError with 404 status code.
+// The League client returns 404 for resources that do not exist yet, such as
+// the champion select session outside of champion select.
+func IsNotFound(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// IsConnectionError returns true if err is returned because the League client did not respond,
+// e.g. the League client was closed.
+func IsConnectionError(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// Client is a client for the League client API (LCU)
+type Client struct {
+	Protocol string
+	Port     string
+	Password string
+
+	httpClient *http.Client
+}
+
+// NewClient creates a League client API client. If httpClient is nil, a client which
+// accepts the self-signed certificate of the League client is used.
+func NewClient(httpClient *http.Client, protocol string, port string, pass string) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		}}
+	}
+
+	return &Client{
+		Protocol:   protocol,
+		Port:       port,
+		Password:   pass,
+		httpClient: httpClient,
+	}
+}
+
+// Subscribe subscribes to the given events of the League client. See Subscribe.
+func (c *Client) Subscribe(events ...string) (*EventListener, error) {
+	return Subscribe(c.Protocol, c.Port, c.Password, events...)
+}
+
+// GetCurrentSummoner returns login information
+func (c *Client) GetCurrentSummoner() (account *datatype.AccountInfo, err error) {
+	err = c.request("GET", "/lol-summoner/v1/current-summoner", nil, http.StatusOK, &account)
+	return account, err
+}
+
+// GetSession returns the current champion select session
+func (c *Client) GetSession() (session *datatype.ChampSelect, err error) {
+	err = c.request("GET", "/lol-champ-select/v1/session", nil, http.StatusOK, &session)
+	return session, err
+}
+
+// PatchMySelection updates summoner spells of the user in champion select
+func (c *Client) PatchMySelection(spells *datatype.Spells) error {
+	return c.request("PATCH", "/lol-champ-select/v1/session/my-selection", spells, http.StatusNoContent, nil)
+}
+
+// GetQueue returns queue information of the current lobby
+func (c *Client) GetQueue() (queue *datatype.QueueInfo, err error) {
+	err = c.request("GET", "/lol-gameflow/v1/gameflow-metadata/player-status", nil, http.StatusOK, &queue)
+	return queue, err
+}
+
+// GetGameflowPhase returns the gameflow phase of the client (e.g. "Lobby", "ChampSelect", "InProgress")
+func (c *Client) GetGameflowPhase() (phase string, err error) {
+	err = c.request("GET", "/lol-gameflow/v1/gameflow-phase", nil, http.StatusOK, &phase)
+	return phase, err
+}
+
+// GetUxState returns the UX state of the client (e.g. "ShowMain")
+func (c *Client) GetUxState() (state string, err error) {
+	err = c.request("GET", "/riotclient/ux-state", nil, http.StatusOK, &state)
+	return state, err
+}
+
+// GetChampion returns champion information of champId owned by summonerId
+func (c *Client) GetChampion(summonerId int, champId int) (champion *datatype.Champion, err error) {
+	endpoint := "/lol-champions/v1/inventories/" + strconv.Itoa(summonerId) + "/champions/" + strconv.Itoa(champId)
+	err = c.request("GET", endpoint, nil, http.StatusOK, &champion)
+	return champion, err
+}
+
+// GetRunePages returns every rune page of the user, including default pages
+func (c *Client) GetRunePages() (pages datatype.RunePages, err error) {
+	err = c.request("GET", "/lol-perks/v1/pages", nil, http.StatusOK, &pages)
+	return pages, err
+}
+
+// GetRunePageCount returns the number of rune pages the user owns
+func (c *Client) GetRunePageCount() (count *datatype.RunePageCount, err error) {
+	err = c.request("GET", "/lol-perks/v1/inventory", nil, http.StatusOK, &count)
+	return count, err
+}
+
+// CreateRunePage creates a new rune page
+func (c *Client) CreateRunePage(page *datatype.RunePage) error {
+	return c.request("POST", "/lol-perks/v1/pages", page, http.StatusOK, nil)
+}
+
+// UpdateRunePage replaces the rune page with page.ID
+func (c *Client) UpdateRunePage(page *datatype.RunePage) error {
+	return c.request("PUT", "/lol-perks/v1/pages/"+strconv.Itoa(page.ID), page, http.StatusCreated, nil)
+}
+
+// SetCurrentRunePage selects the rune page with runePageId
+func (c *Client) SetCurrentRunePage(runePageId int) error {
+	return c.request("PUT", "/lol-perks/v1/currentpage", runePageId, http.StatusNoContent, nil)
+}
+
+// DeleteRunePage deletes the rune page with runePageId
+func (c *Client) DeleteRunePage(runePageId int) error {
+	return c.request("DELETE", "/lol-perks/v1/pages/"+strconv.Itoa(runePageId), nil, http.StatusNoContent, nil)
+}
+
+// GetItemSets returns item sets of summonerId
+func (c *Client) GetItemSets(summonerId int) (itemPage *datatype.ItemPage, err error) {
+	endpoint := "/lol-item-sets/v1/item-sets/" + strconv.Itoa(summonerId) + "/sets"
+	if err = c.request("GET", endpoint, nil, http.StatusOK, &itemPage); err != nil {
+		return nil, err
+	}
+	return itemPage, nil
+}
+
+// PutItemSets replaces item sets of summonerId with itemPage
+func (c *Client) PutItemSets(summonerId int, itemPage *datatype.ItemPage) error {
+	endpoint := "/lol-item-sets/v1/item-sets/" + strconv.Itoa(summonerId) + "/sets"
+	return c.request("PUT", endpoint, itemPage, http.StatusCreated, nil)
+}
+
+// request sends a request to endpoint with body encoded as JSON, and decodes the response to out
+// if the response has expectedStatus. out and body can be nil.
+func (c *Client) request(method string, endpoint string, body interface{}, expectedStatus int, out interface{}) (err error) {
+	var reqBody io.Reader
+	if body != nil {
+		b := new(bytes.Buffer)
+		if err = json.NewEncoder(b).Encode(body); err != nil {
+			return err
+		}
+		reqBody = b
+	}
+
+	req, err := http.NewRequest(method, c.Protocol+"://127.0.0.1:"+c.Port+endpoint, reqBody)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth("riot", c.Password)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatus {
+		statusErr := &StatusError{
+			Method:     method,
+			Endpoint:   endpoint,
+			StatusCode: resp.StatusCode,
+		}
+		// Error responses of the League client have a message field
+		var errResp struct {
+			Message string `json:"message"`
+		}
+		if b, err := ioutil.ReadAll(resp.Body); err == nil && json.Unmarshal(b, &errResp) == nil {
+			statusErr.Message = errResp.Message
+		}
+		return statusErr
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,111 @@
+package lcu
+
+import (
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/internal/lcu/lcutest"
+	"net/http"
+	"testing"
+)
+
+func newTestClient(t *testing.T) (*Client, *lcutest.Server) {
+	server := lcutest.NewServer(datatype.AccountInfo{AccountID: 5678, SummonerID: 1234})
+	t.Cleanup(server.Close)
+	return NewClient(nil, "https", server.Port(), server.Password), server
+}
+
+func TestClientStatusError(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	_, err := client.GetSession()
+	if !IsNotFound(err) {
+		t.Error("Incorrect result for GetSession outside of champion select: ", err)
+	}
+
+	statusErr, ok := err.(*StatusError)
+	if !ok || statusErr.Message != "No active delegate" || statusErr.Endpoint != "/lol-champ-select/v1/session" {
+		t.Error("Incorrect status error: ", err)
+	}
+
+	client.Password = "wrong"
+	if _, err = client.GetCurrentSummoner(); err == nil || IsNotFound(err) {
+		t.Error("Incorrect result for GetCurrentSummoner with incorrect password: ", err)
+	} else if err.(*StatusError).StatusCode != http.StatusUnauthorized {
+		t.Error("Incorrect status code: ", err)
+	}
+}
+
+func TestClientEndpoints(t *testing.T) {
+	client, server := newTestClient(t)
+
+	account, err := client.GetCurrentSummoner()
+	if err != nil || account.SummonerID != 1234 {
+		t.Error("Incorrect result for GetCurrentSummoner: ", err)
+	}
+
+	server.SetQueueID(450)
+	if queue, err := client.GetQueue(); err != nil || queue.CurrentLobbyStatus.QueueID != 450 {
+		t.Error("Incorrect result for GetQueue: ", err)
+	}
+
+	if state, err := client.GetUxState(); err != nil || state != "ShowMain" {
+		t.Error("Incorrect result for GetUxState: ", state, err)
+	}
+
+	server.AddChampion(datatype.Champion{ID: 103, Alias: "Ahri"})
+	if champion, err := client.GetChampion(1234, 103); err != nil || champion.Alias != "Ahri" {
+		t.Error("Incorrect result for GetChampion: ", err)
+	}
+
+	server.SetSession(&datatype.ChampSelect{LocalPlayerCellID: 2})
+	if session, err := client.GetSession(); err != nil || session.LocalPlayerCellID != 2 {
+		t.Error("Incorrect result for GetSession: ", err)
+	}
+	if phase, err := client.GetGameflowPhase(); err != nil || phase != "ChampSelect" {
+		t.Error("Incorrect result for GetGameflowPhase: ", phase, err)
+	}
+
+	if err = client.PatchMySelection(&datatype.Spells{Spell1ID: 4, Spell2ID: 14}); err != nil {
+		t.Error(err)
+	}
+	if server.Selection().Spell2ID != 14 {
+		t.Error("Incorrect result for PatchMySelection")
+	}
+
+	if err = client.PutItemSets(1234, &datatype.ItemPage{AccountID: 5678, ItemSets: []datatype.ItemSet{{Title: "Set"}}}); err != nil {
+		t.Error(err)
+	}
+	if itemPage, ok := server.ItemPage(1234); !ok || itemPage.ItemSets[0].Title != "Set" {
+		t.Error("Incorrect result for PutItemSets")
+	}
+}
+
+func TestClientRunePages(t *testing.T) {
+	client, server := newTestClient(t)
+	server.SetRunePages(nil, 1)
+
+	if count, err := client.GetRunePageCount(); err != nil || count.OwnedPageCount != 1 {
+		t.Error("Incorrect result for GetRunePageCount: ", err)
+	}
+
+	if err := client.CreateRunePage(&datatype.RunePage{Name: "First"}); err != nil {
+		t.Error(err)
+	}
+
+	// Only one page is owned
+	if err := client.CreateRunePage(&datatype.RunePage{Name: "Second"}); err == nil {
+		t.Error("CreateRunePage should fail if every page is used")
+	}
+
+	pages, err := client.GetRunePages()
+	if err != nil || len(pages) != 1 || pages[0].Name != "First" {
+		t.Fatal("Incorrect result for GetRunePages: ", err)
+	}
+
+	if err = client.DeleteRunePage(pages[0].ID); err != nil {
+		t.Error(err)
+	}
+
+	if err = client.DeleteRunePage(pages[0].ID); !IsNotFound(err) {
+		t.Error("Incorrect result for deleting a page twice: ", err)
+	}
+}
@@ -0,0 +1,169 @@
+package lcu
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LockfileName is the name of the file the League client creates in its install directory while running
+const LockfileName = "lockfile"
+
+// probeTimeout is how long Listening waits for the client API to accept a connection
+const probeTimeout = 500 * time.Millisecond
+
+// clientProcessName is the name of the League client process which serves the client API
+const clientProcessName = "LeagueClientUx"
+
+var invalidLockfileError = errors.New("invalid lockfile")
+
+var clientNotFoundError = errors.New("league client process not found")
+
+var (
+	appPortArg   = regexp.MustCompile(`--app-port=(\d+)`)
+	authTokenArg = regexp.MustCompile(`--remoting-auth-token=([\w-]+)`)
+)
+
+// Credentials are used to connect to the client API of a running League client
+type Credentials struct {
+	PID      int // 0 if unknown
+	Port     string
+	Password string
+	Protocol string
+}
+
+// ParseLockfile parses the content of a lockfile, "LeagueClient:<pid>:<port>:<password>:<protocol>"
+func ParseLockfile(content string) (*Credentials, error) {
+	values := strings.Split(strings.TrimSpace(content), ":")
+	if len(values) != 5 || values[2] == "" || values[3] == "" {
+		return nil, invalidLockfileError
+	}
+
+	pid, err := strconv.Atoi(values[1])
+	if err != nil {
+		return nil, invalidLockfileError
+	}
+
+	return &Credentials{PID: pid, Port: values[2], Password: values[3], Protocol: values[4]}, nil
+}
+
+// Listening returns true if the client API accepts connections on the port of c. The lockfile is left behind
+// when the League client crashes, so credentials read from it may belong to a closed League client.
+// The port is probed instead of the PID, as the PID of the client running on Wine is not a PID of the host.
+func (c *Credentials) Listening() bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", c.Port), probeTimeout)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// ReadLockfile reads the lockfile in dir
+func ReadLockfile(dir string) (*Credentials, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, LockfileName))
+	if err != nil {
+		return nil, err
+	}
+	return ParseLockfile(string(b))
+}
+
+// ParseCommandLine returns credentials in the command line of the LeagueClientUx process.
+// The client API always uses https.
+func ParseCommandLine(commandLine string) (*Credentials, bool) {
+	port := appPortArg.FindStringSubmatch(commandLine)
+	token := authTokenArg.FindStringSubmatch(commandLine)
+	if port == nil || token == nil {
+		return nil, false
+	}
+	return &Credentials{Port: port[1], Password: token[1], Protocol: "https"}, true
+}
+
+// FindClientProcess returns credentials of the running League client from the process table
+func FindClientProcess() (*Credentials, error) {
+	switch runtime.GOOS {
+	case "windows":
+		out, err := exec.Command("wmic", "process", "where", "name='"+clientProcessName+".exe'", "get", "commandline").Output()
+		if err != nil {
+			return nil, err
+		}
+		return findInCommandLines(strings.Split(string(out), "\n"))
+	case "darwin":
+		out, err := exec.Command("ps", "-A", "-o", "args=").Output()
+		if err != nil {
+			return nil, err
+		}
+		return findInCommandLines(strings.Split(string(out), "\n"))
+	default:
+		// Linux, including the client running on Wine
+		return scanProcDir("/proc")
+	}
+}
+
+// findInCommandLines returns credentials in the first command line of the LeagueClientUx process
+func findInCommandLines(commandLines []string) (*Credentials, error) {
+	for _, commandLine := range commandLines {
+		if !strings.Contains(commandLine, clientProcessName) {
+			continue
+		}
+		if credentials, ok := ParseCommandLine(commandLine); ok {
+			return credentials, nil
+		}
+	}
+	return nil, clientNotFoundError
+}
+
+// scanProcDir finds the LeagueClientUx process in procDir, which has the structure of /proc
+func scanProcDir(procDir string) (*Credentials, error) {
+	entries, err := ioutil.ReadDir(procDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		// Processes may exit while scanning
+		b, err := ioutil.ReadFile(filepath.Join(procDir, entry.Name(), "cmdline"))
+		if err != nil || !strings.Contains(string(b), clientProcessName) {
+			continue
+		}
+		// Arguments are separated by NUL
+		if credentials, ok := ParseCommandLine(strings.ReplaceAll(string(b), "\x00", " ")); ok {
+			credentials.PID = pid
+			return credentials, nil
+		}
+	}
+
+	return nil, clientNotFoundError
+}
+
+// InstallDirs returns known install directories of the League client on the current platform
+func InstallDirs() []string {
+	switch runtime.GOOS {
+	case "windows":
+		return []string{"C:/Riot Games/League of Legends", "D:/Riot Games/League of Legends"}
+	case "darwin":
+		return []string{"/Applications/League of Legends.app/Contents/LoL"}
+	default:
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil
+		}
+		// Wine prefixes of Lutris and the default prefix
+		return []string{
+			filepath.Join(home, "Games/league-of-legends/drive_c/Riot Games/League of Legends"),
+			filepath.Join(home, ".wine/drive_c/Riot Games/League of Legends"),
+		}
+	}
+}
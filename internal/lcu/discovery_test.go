@@ -0,0 +1,85 @@
+package lcu
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseLockfile(t *testing.T) {
+	credentials, err := ParseLockfile("LeagueClient:1234:54321:secret-token:https\n")
+	if err != nil || !reflect.DeepEqual(*credentials, Credentials{PID: 1234, Port: "54321", Password: "secret-token", Protocol: "https"}) {
+		t.Error("Incorrect credentials: ", credentials, err)
+	}
+
+	// Lockfiles may be read while the League client is writing them
+	for _, content := range []string{"", "LeagueClient:1234:54321", "LeagueClient:pid:54321:secret-token:https"} {
+		if _, err = ParseLockfile(content); err != invalidLockfileError {
+			t.Error("Incorrect error for ", content, ": ", err)
+		}
+	}
+}
+
+func TestParseCommandLine(t *testing.T) {
+	commandLine := `"C:/Riot Games/League of Legends/LeagueClientUx.exe" "--riotclient-auth-token=other" ` +
+		`"--riotclient-app-port=11111" "--app-port=54321" "--remoting-auth-token=secret_token-1" "--app-pid=1234"`
+	credentials, ok := ParseCommandLine(commandLine)
+	if !ok || credentials.Port != "54321" || credentials.Password != "secret_token-1" || credentials.Protocol != "https" {
+		t.Error("Incorrect credentials: ", credentials)
+	}
+
+	if _, ok = ParseCommandLine("LeagueClientUx.exe --app-port=54321"); ok {
+		t.Error("Command line without a token should be ignored")
+	}
+}
+
+func TestScanProcDir(t *testing.T) {
+	procDir := t.TempDir()
+	processes := map[string]string{
+		"1":    "/sbin/init\x00",
+		"42":   "C:\\Riot Games\\Riot Client\\RiotClientServices.exe\x00--app-port=11111\x00",
+		"4321": "C:\\Riot Games\\League of Legends\\LeagueClientUx.exe\x00--app-port=54321\x00--remoting-auth-token=secret\x00",
+		"self": "not a process\x00",
+	}
+	for pid, cmdline := range processes {
+		if err := os.MkdirAll(filepath.Join(procDir, pid), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(procDir, pid, "cmdline"), []byte(cmdline), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	credentials, err := scanProcDir(procDir)
+	if err != nil || credentials.PID != 4321 || credentials.Port != "54321" || credentials.Password != "secret" {
+		t.Error("Incorrect credentials: ", credentials, err)
+	}
+
+	if err = os.RemoveAll(filepath.Join(procDir, "4321")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = scanProcDir(procDir); err != clientNotFoundError {
+		t.Error("Incorrect error without the League client: ", err)
+	}
+}
+
+func TestListening(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	credentials := &Credentials{Port: port, Password: "secret", Protocol: "https"}
+	if !credentials.Listening() {
+		t.Error("Running client API is not detected")
+	}
+
+	// The League client crashed and left its lockfile
+	_ = listener.Close()
+	if credentials.Listening() {
+		t.Error("Closed client API is detected")
+	}
+}
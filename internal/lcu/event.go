@@ -0,0 +1,140 @@
+package lcu
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"sync"
+)
+
+// WAMP 1.0 message type IDs used by the League client event stream
+const (
+	wampSubscribe = 5
+	wampEvent     = 8
+)
+
+// Event names published by the League client
+const (
+	ChampSelectSessionEvent = "OnJsonApiEvent_lol-champ-select_v1_session"
+	GameflowPhaseEvent      = "OnJsonApiEvent_lol-gameflow_v1_gameflow-phase"
+)
+
+var invalidMessageError = errors.New("invalid event message")
+
+// Event is a single update pushed by the League client
+type Event struct {
+	Name      string          `json:"-"`
+	Data      json.RawMessage `json:"data"`
+	EventType string          `json:"eventType"`
+	URI       string          `json:"uri"`
+}
+
+// EventListener receives events from the League client WebSocket
+type EventListener struct {
+	Events chan *Event
+
+	conn      *websocket.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Subscribe connects to the WebSocket of the League client and subscribes to the given events.
+// Received events are delivered through EventListener.Events, which is closed once the connection is lost.
+func Subscribe(protocol string, port string, pass string, events ...string) (listener *EventListener, err error) {
+	scheme := "ws"
+	if protocol == "https" {
+		scheme = "wss"
+	}
+
+	dialer := websocket.Dialer{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	header := http.Header{}
+	header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("riot:"+pass)))
+
+	conn, _, err := dialer.Dial(scheme+"://127.0.0.1:"+port+"/", header)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		if err = conn.WriteJSON([]interface{}{wampSubscribe, event}); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	listener = &EventListener{
+		Events: make(chan *Event, 16),
+		conn:   conn,
+		done:   make(chan struct{}),
+	}
+	go listener.listen()
+
+	return listener, nil
+}
+
+// Close closes the connection to the League client
+func (listener *EventListener) Close() error {
+	var err error
+	listener.closeOnce.Do(func() {
+		close(listener.done)
+		err = listener.conn.Close()
+	})
+	return err
+}
+
+// listen reads messages until the connection is closed
+func (listener *EventListener) listen() {
+	defer close(listener.Events)
+
+	for {
+		_, msg, err := listener.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		event, err := parseEvent(msg)
+		if err != nil {
+			// Ignore anything that is not an event (e.g. welcome or empty messages)
+			continue
+		}
+
+		select {
+		case listener.Events <- event:
+		case <-listener.done:
+			return
+		}
+	}
+}
+
+// parseEvent decodes a WAMP event message: [8, "EventName", {...}]
+func parseEvent(msg []byte) (event *Event, err error) {
+	var fields []json.RawMessage
+	if err = json.Unmarshal(msg, &fields); err != nil {
+		return nil, err
+	}
+
+	if len(fields) != 3 {
+		return nil, invalidMessageError
+	}
+
+	var msgType int
+	if err = json.Unmarshal(fields[0], &msgType); err != nil || msgType != wampEvent {
+		return nil, invalidMessageError
+	}
+
+	event = &Event{}
+	if err = json.Unmarshal(fields[1], &event.Name); err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(fields[2], event); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
@@ -0,0 +1,141 @@
+package lcu
+
+import (
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newFakeEventServer creates a WebSocket server which waits for subscriptions and
+// replays the given messages in order.
+func newFakeEventServer(t *testing.T, pass string, subscriptions int, messages []json.RawMessage) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, p, ok := r.BasicAuth(); !ok || user != "riot" || p != pass {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		for i := 0; i < subscriptions; i++ {
+			var msg []interface{}
+			if err = conn.ReadJSON(&msg); err != nil {
+				t.Error(err)
+				return
+			}
+			if len(msg) != 2 || msg[0] != float64(wampSubscribe) {
+				t.Error("Incorrect subscribe message: ", msg)
+				return
+			}
+		}
+
+		for _, msg := range messages {
+			if err = conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}))
+}
+
+func readFixture(t *testing.T, name string) (messages []json.RawMessage) {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(b, &messages); err != nil {
+		t.Fatal(err)
+	}
+	return messages
+}
+
+func serverPort(t *testing.T, server *httptest.Server) string {
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Port()
+}
+
+func TestSubscribe(t *testing.T) {
+	server := newFakeEventServer(t, "password", 2, readFixture(t, "champ_select_events.json"))
+	defer server.Close()
+
+	listener, err := Subscribe("https", serverPort(t, server), "password", ChampSelectSessionEvent, GameflowPhaseEvent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	expected := []struct {
+		name      string
+		eventType string
+	}{
+		{GameflowPhaseEvent, "Update"},
+		{ChampSelectSessionEvent, "Create"},
+		{ChampSelectSessionEvent, "Update"},
+		{ChampSelectSessionEvent, "Update"},
+		{ChampSelectSessionEvent, "Delete"},
+		{GameflowPhaseEvent, "Update"},
+	}
+
+	for i, exp := range expected {
+		select {
+		case event, ok := <-listener.Events:
+			if !ok {
+				t.Fatalf("Event channel closed after %d events", i)
+			}
+			if event.Name != exp.name || event.EventType != exp.eventType {
+				t.Errorf("Incorrect event %d: %s %s", i, event.Name, event.EventType)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for event %d", i)
+		}
+	}
+
+	// Server closes the connection once all messages are replayed
+	select {
+	case _, ok := <-listener.Events:
+		if ok {
+			t.Error("Unexpected event")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Event channel was not closed")
+	}
+}
+
+func TestSubscribeUnauthorized(t *testing.T) {
+	server := newFakeEventServer(t, "password", 0, nil)
+	defer server.Close()
+
+	if _, err := Subscribe("https", serverPort(t, server), "wrong", ChampSelectSessionEvent); err == nil {
+		t.Error("Subscribe should fail with incorrect password")
+	}
+}
+
+func TestParseEvent(t *testing.T) {
+	if _, err := parseEvent([]byte(`[0, "session", 1, "Riot Games, Inc."]`)); err == nil {
+		t.Error("Welcome message should not be parsed as an event")
+	}
+
+	event, err := parseEvent([]byte(`[8, "` + GameflowPhaseEvent + `", {"data": "Lobby", "eventType": "Update", "uri": "/lol-gameflow/v1/gameflow-phase"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Name != GameflowPhaseEvent || string(event.Data) != `"Lobby"` || event.URI != "/lol-gameflow/v1/gameflow-phase" {
+		t.Error("Incorrect result for parseEvent")
+	}
+}
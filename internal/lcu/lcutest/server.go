@@ -0,0 +1,372 @@
+// Package lcutest provides a fake League client for tests
+package lcutest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Server is a fake League client API server. Every method is safe for concurrent use.
+type Server struct {
+	Password string
+
+	server         *httptest.Server
+	mu             sync.Mutex
+	account        datatype.AccountInfo
+	session        *datatype.ChampSelect
+	queue          datatype.QueueInfo
+	uxState        string
+	phase          string
+	champions      map[int]datatype.Champion
+	runePages      datatype.RunePages
+	ownedPageCount int
+	nextPageId     int
+	itemPages      map[int]datatype.ItemPage
+	selection      datatype.Spells
+	requests       []string
+	failures       map[string]int
+}
+
+// NewServer starts a fake League client logged in as account.
+// The server is not in champion select, the gameflow phase is "None" and the UX state is "ShowMain" initially.
+func NewServer(account datatype.AccountInfo) *Server {
+	s := &Server{
+		Password:       "fake-password",
+		account:        account,
+		uxState:        "ShowMain",
+		phase:          "None",
+		champions:      make(map[int]datatype.Champion),
+		ownedPageCount: 2,
+		nextPageId:     1000,
+		itemPages:      make(map[int]datatype.ItemPage),
+		failures:       make(map[string]int),
+	}
+	s.server = httptest.NewUnstartedServer(http.HandlerFunc(s.handle))
+	// DFF probes the port before connecting, which fails TLS handshakes
+	s.server.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	s.server.StartTLS()
+	return s
+}
+
+// Close shuts down the server
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Port returns the port the server is listening on
+func (s *Server) Port() string {
+	u, _ := url.Parse(s.server.URL)
+	return u.Port()
+}
+
+// Lockfile returns the content of a lockfile pointing to the server
+func (s *Server) Lockfile() string {
+	return fmt.Sprintf("LeagueClient:%d:%s:%s:https", os.Getpid(), s.Port(), s.Password)
+}
+
+// WriteLockfile writes a lockfile pointing to the server into dir
+func (s *Server) WriteLockfile(dir string) error {
+	return ioutil.WriteFile(filepath.Join(dir, "lockfile"), []byte(s.Lockfile()), 0644)
+}
+
+// SetSession sets the champion select session. nil means the user is not in champion select.
+// The gameflow phase follows the session: it changes to "ChampSelect" when a session is set, and back to
+// "None" when the session is removed in champion select. Other transitions are made with SetPhase.
+func (s *Server) SetSession(session *datatype.ChampSelect) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.session = session
+	if session != nil {
+		s.phase = "ChampSelect"
+	} else if s.phase == "ChampSelect" {
+		s.phase = "None"
+	}
+}
+
+// SetPhase sets the gameflow phase of the client
+func (s *Server) SetPhase(phase string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.phase = phase
+}
+
+// SetQueueID sets the queue of the current lobby
+func (s *Server) SetQueueID(queueId int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue.CurrentLobbyStatus.QueueID = queueId
+}
+
+// SetUxState sets the UX state of the client
+func (s *Server) SetUxState(state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.uxState = state
+}
+
+// AddChampion adds a champion to the champion inventory
+func (s *Server) AddChampion(champion datatype.Champion) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.champions[champion.ID] = champion
+}
+
+// SetRunePages replaces every rune page and sets the number of owned rune pages
+func (s *Server) SetRunePages(pages datatype.RunePages, ownedPageCount int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runePages = append(datatype.RunePages{}, pages...)
+	s.ownedPageCount = ownedPageCount
+}
+
+// RunePages returns current rune pages
+func (s *Server) RunePages() datatype.RunePages {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append(datatype.RunePages{}, s.runePages...)
+}
+
+// SetItemPage replaces item sets of summonerId
+func (s *Server) SetItemPage(summonerId int, itemPage datatype.ItemPage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.itemPages[summonerId] = itemPage
+}
+
+// ItemPage returns item sets of summonerId
+func (s *Server) ItemPage(summonerId int) (itemPage datatype.ItemPage, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	itemPage, ok = s.itemPages[summonerId]
+	return itemPage, ok
+}
+
+// Selection returns summoner spells selected in champion select
+func (s *Server) Selection() datatype.Spells {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.selection
+}
+
+// SetFailure makes requests with method to path fail with status. status 0 removes the failure.
+func (s *Server) SetFailure(method string, path string, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status == 0 {
+		delete(s.failures, method+" "+path)
+		return
+	}
+	s.failures[method+" "+path] = status
+}
+
+// Requests returns every request received, formatted as "METHOD /path"
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.requests...)
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if user, pass, ok := r.BasicAuth(); !ok || user != "riot" || pass != s.Password {
+		writeError(w, http.StatusUnauthorized, "Invalid credentials")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	if status, ok := s.failures[r.Method+" "+r.URL.Path]; ok {
+		writeError(w, status, "Failure set by the test")
+		return
+	}
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/lol-summoner/v1/current-summoner":
+		writeJson(w, http.StatusOK, s.account)
+	case r.Method == "GET" && r.URL.Path == "/lol-champ-select/v1/session":
+		if s.session == nil {
+			writeError(w, http.StatusNotFound, "No active delegate")
+			return
+		}
+		writeJson(w, http.StatusOK, s.session)
+	case r.Method == "PATCH" && r.URL.Path == "/lol-champ-select/v1/session/my-selection":
+		if s.session == nil {
+			writeError(w, http.StatusNotFound, "No active delegate")
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&s.selection); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == "GET" && r.URL.Path == "/lol-gameflow/v1/gameflow-metadata/player-status":
+		writeJson(w, http.StatusOK, s.queue)
+	case r.Method == "GET" && r.URL.Path == "/lol-gameflow/v1/gameflow-phase":
+		writeJson(w, http.StatusOK, s.phase)
+	case r.Method == "GET" && r.URL.Path == "/riotclient/ux-state":
+		writeJson(w, http.StatusOK, s.uxState)
+	case r.Method == "GET" && len(path) == 6 && path[0] == "lol-champions" && path[4] == "champions":
+		champId, _ := strconv.Atoi(path[5])
+		champion, ok := s.champions[champId]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Champion not found")
+			return
+		}
+		writeJson(w, http.StatusOK, champion)
+	case r.Method == "GET" && r.URL.Path == "/lol-perks/v1/inventory":
+		writeJson(w, http.StatusOK, datatype.RunePageCount{OwnedPageCount: s.ownedPageCount})
+	case r.Method == "GET" && r.URL.Path == "/lol-perks/v1/pages":
+		writeJson(w, http.StatusOK, s.runePages)
+	case r.Method == "POST" && r.URL.Path == "/lol-perks/v1/pages":
+		s.createRunePage(w, r)
+	case r.Method == "PUT" && len(path) == 4 && path[0] == "lol-perks" && path[2] == "pages":
+		s.updateRunePage(w, r, path[3])
+	case r.Method == "PUT" && r.URL.Path == "/lol-perks/v1/currentpage":
+		s.setCurrentRunePage(w, r)
+	case r.Method == "DELETE" && len(path) == 4 && path[0] == "lol-perks" && path[2] == "pages":
+		s.deleteRunePage(w, path[3])
+	case len(path) == 5 && path[0] == "lol-item-sets" && path[4] == "sets":
+		summonerId, _ := strconv.Atoi(path[3])
+		if r.Method == "GET" {
+			writeJson(w, http.StatusOK, s.itemPages[summonerId])
+			return
+		} else if r.Method == "PUT" {
+			var itemPage datatype.ItemPage
+			if err := json.NewDecoder(r.Body).Decode(&itemPage); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			s.itemPages[summonerId] = itemPage
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	default:
+		writeError(w, http.StatusNotFound, "Resource not found")
+	}
+}
+
+func (s *Server) createRunePage(w http.ResponseWriter, r *http.Request) {
+	var page datatype.RunePage
+	if err := json.NewDecoder(r.Body).Decode(&page); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	deletable := 0
+	for _, p := range s.runePages {
+		if p.IsDeletable {
+			deletable++
+		}
+	}
+	if deletable >= s.ownedPageCount {
+		writeError(w, http.StatusBadRequest, "Max pages reached")
+		return
+	}
+
+	page.ID = s.nextPageId
+	page.IsDeletable = true
+	page.IsEditable = true
+	s.nextPageId++
+
+	// Newly created page is always placed at the front
+	s.runePages = append(datatype.RunePages{page}, s.runePages...)
+	writeJson(w, http.StatusOK, page)
+}
+
+func (s *Server) updateRunePage(w http.ResponseWriter, r *http.Request, id string) {
+	pageId, err := strconv.Atoi(id)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var page datatype.RunePage
+	if err = json.NewDecoder(r.Body).Decode(&page); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	for i := range s.runePages {
+		if s.runePages[i].ID == pageId {
+			if !s.runePages[i].IsEditable {
+				writeError(w, http.StatusBadRequest, "Page is not editable")
+				return
+			}
+			page.ID = pageId
+			page.IsDeletable = s.runePages[i].IsDeletable
+			page.IsEditable = true
+			s.runePages[i] = page
+			writeJson(w, http.StatusCreated, page)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Page not found")
+}
+
+func (s *Server) setCurrentRunePage(w http.ResponseWriter, r *http.Request) {
+	var pageId int
+	if err := json.NewDecoder(r.Body).Decode(&pageId); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	found := false
+	for i := range s.runePages {
+		s.runePages[i].Current = s.runePages[i].ID == pageId
+		found = found || s.runePages[i].Current
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, "Page not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) deleteRunePage(w http.ResponseWriter, id string) {
+	pageId, err := strconv.Atoi(id)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	for i, page := range s.runePages {
+		if page.ID == pageId {
+			if !page.IsDeletable {
+				writeError(w, http.StatusBadRequest, "Page is not deletable")
+				return
+			}
+			s.runePages = append(s.runePages[:i], s.runePages[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Page not found")
+}
+
+func writeJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJson(w, status, map[string]interface{}{
+		"errorCode":  "RPC_ERROR",
+		"httpStatus": status,
+		"message":    msg,
+	})
+}
@@ -0,0 +1,254 @@
+package lcu
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecordingVersion is used to keep track of recording file versions.
+// If Recording or Interaction is edited in any way, this value must be incremented.
+const RecordingVersion uint16 = 1
+
+var incompatibleRecordingError = errors.New("recording is incompatible")
+
+// Interaction is a request made to the League client and its response
+type Interaction struct {
+	Method       string          `json:"method"`
+	Path         string          `json:"path"` // includes the query, without the host
+	RequestBody  json.RawMessage `json:"request_body,omitempty"`
+	StatusCode   int             `json:"status_code"`
+	ResponseBody json.RawMessage `json:"response_body,omitempty"`
+}
+
+// Recording is a sequence of interactions with the League client, in the order they were made
+type Recording struct {
+	Version      uint16        `json:"version"`
+	CreationTime time.Time     `json:"creation_time"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// ReadRecording reads a recording saved with Recording.Write
+func ReadRecording(filename string) (recording *Recording, err error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(b, &recording); err != nil {
+		return nil, err
+	}
+
+	if recording.Version != RecordingVersion {
+		return nil, incompatibleRecordingError
+	}
+
+	return recording, nil
+}
+
+// Write saves the recording to filename
+func (r *Recording) Write(filename string) error {
+	b, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, b, 0644)
+}
+
+// RecordingTransport is a http.RoundTripper which records every request made through it.
+// Credentials and the host are not recorded.
+type RecordingTransport struct {
+	transport http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// NewRecordingTransport creates a RecordingTransport which sends requests with transport
+func NewRecordingTransport(transport http.RoundTripper) *RecordingTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &RecordingTransport{transport: transport}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = b
+		req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	t.mu.Lock()
+	t.interactions = append(t.interactions, Interaction{
+		Method:       req.Method,
+		Path:         req.URL.RequestURI(),
+		RequestBody:  rawJson(reqBody),
+		StatusCode:   resp.StatusCode,
+		ResponseBody: rawJson(respBody),
+	})
+	t.mu.Unlock()
+
+	return resp, nil
+}
+
+// Len returns the number of recorded interactions
+func (t *RecordingTransport) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.interactions)
+}
+
+// Flush returns interactions recorded so far and starts a new recording
+func (t *RecordingTransport) Flush() *Recording {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	recording := &Recording{
+		Version:      RecordingVersion,
+		CreationTime: time.Now(),
+		Interactions: t.interactions,
+	}
+	t.interactions = nil
+
+	return recording
+}
+
+// ReplayTransport is a http.RoundTripper which serves responses of a Recording.
+// Responses of each method and path are served in the recorded order, and the last
+// one is repeated once they run out, so a client making the same sequence of requests
+// always sees the same responses regardless of timing. Requests which were never
+// recorded receive 404.
+type ReplayTransport struct {
+	mu        sync.Mutex
+	responses map[string][]Interaction
+	last      map[string]Interaction
+	requests  []Interaction
+}
+
+// NewReplayTransport creates a ReplayTransport which serves responses of recording
+func NewReplayTransport(recording *Recording) *ReplayTransport {
+	t := &ReplayTransport{
+		responses: make(map[string][]Interaction),
+		last:      make(map[string]Interaction),
+	}
+	for _, interaction := range recording.Interactions {
+		key := interactionKey(interaction.Method, interaction.Path)
+		t.responses[key] = append(t.responses[key], interaction)
+	}
+	return t
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		b, err := ioutil.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		reqBody = b
+	}
+
+	path := req.URL.RequestURI()
+	key := interactionKey(req.Method, path)
+
+	t.mu.Lock()
+	interaction, ok := t.last[key]
+	if queue := t.responses[key]; len(queue) > 0 {
+		interaction, ok = queue[0], true
+		t.responses[key] = queue[1:]
+		t.last[key] = interaction
+	}
+	if !ok {
+		interaction = Interaction{
+			StatusCode:   http.StatusNotFound,
+			ResponseBody: json.RawMessage(`{"message":"not recorded"}`),
+		}
+	}
+	t.requests = append(t.requests, Interaction{
+		Method:       req.Method,
+		Path:         path,
+		RequestBody:  rawJson(reqBody),
+		StatusCode:   interaction.StatusCode,
+		ResponseBody: interaction.ResponseBody,
+	})
+	t.mu.Unlock()
+
+	return &http.Response{
+		Status:        http.StatusText(interaction.StatusCode),
+		StatusCode:    interaction.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(interaction.ResponseBody)),
+		ContentLength: int64(len(interaction.ResponseBody)),
+		Request:       req,
+	}, nil
+}
+
+// Requests returns every request served so far, with the request body sent by the client
+func (t *ReplayTransport) Requests() []Interaction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Interaction{}, t.requests...)
+}
+
+// Remaining returns the number of recorded responses which are not served yet
+func (t *ReplayTransport) Remaining() (n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, queue := range t.responses {
+		n += len(queue)
+	}
+	return n
+}
+
+func interactionKey(method string, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+// rawJson returns b as json.RawMessage, or nil if b is not valid JSON (e.g. empty body)
+func rawJson(b []byte) json.RawMessage {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || !json.Valid(b) {
+		return nil
+	}
+	return append(json.RawMessage{}, b...)
+}
+
+// WriteRecording saves recording to a new file in dir, named after its creation time
+func WriteRecording(dir string, recording *Recording) (filename string, err error) {
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	filename = filepath.Join(dir, recording.CreationTime.Format("20060102-150405")+".json")
+	return filename, recording.Write(filename)
+}
@@ -0,0 +1,78 @@
+package lcu
+
+import (
+	"crypto/tls"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/internal/lcu/lcutest"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	server := lcutest.NewServer(datatype.AccountInfo{AccountID: 5678, SummonerID: 1234})
+	t.Cleanup(server.Close)
+
+	recorder := NewRecordingTransport(&http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}})
+	client := NewClient(&http.Client{Transport: recorder}, "https", server.Port(), server.Password)
+
+	server.SetQueueID(420)
+	if _, err := client.GetQueue(); err != nil {
+		t.Fatal(err)
+	}
+	server.SetQueueID(450)
+	if _, err := client.GetQueue(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetSession(); !IsNotFound(err) {
+		t.Fatal("Incorrect result for GetSession: ", err)
+	}
+	server.SetSession(&datatype.ChampSelect{})
+	if err := client.PatchMySelection(&datatype.Spells{Spell1ID: 4, Spell2ID: 14}); err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "recording.json")
+	if err := recorder.Flush().Write(filename); err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Len() != 0 {
+		t.Error("Recording is not flushed")
+	}
+
+	recording, err := ReadRecording(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recording.Interactions) != 4 || strings.Contains(string(recording.Interactions[3].RequestBody), server.Password) {
+		t.Fatal("Incorrect recording: ", recording.Interactions)
+	}
+
+	replay := NewReplayTransport(recording)
+	client = NewClient(&http.Client{Transport: replay}, "https", "1", "replay")
+
+	// Responses are served in the recorded order, and the last one is repeated
+	for _, expected := range []int{420, 450, 450} {
+		if queue, err := client.GetQueue(); err != nil || queue.CurrentLobbyStatus.QueueID != expected {
+			t.Error("Incorrect queue: ", queue, err)
+		}
+	}
+	if _, err = client.GetSession(); !IsNotFound(err) {
+		t.Error("Recorded error is not replayed: ", err)
+	}
+	if _, err = client.GetUxState(); !IsNotFound(err) {
+		t.Error("Request which is not recorded should fail: ", err)
+	}
+	if err = client.PatchMySelection(&datatype.Spells{Spell1ID: 4, Spell2ID: 12}); err != nil {
+		t.Error(err)
+	}
+
+	requests := replay.Requests()
+	if len(requests) != 6 || !strings.Contains(string(requests[5].RequestBody), "12") {
+		t.Error("Incorrect requests: ", requests)
+	}
+	if replay.Remaining() != 0 {
+		t.Error("Recorded responses are left: ", replay.Remaining())
+	}
+}
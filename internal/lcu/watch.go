@@ -0,0 +1,93 @@
+package lcu
+
+import (
+	"github.com/fsnotify/fsnotify"
+	"path/filepath"
+	"sync"
+)
+
+// LockfileEvent is a change of a lockfile
+type LockfileEvent struct {
+	Dir     string // directory of the lockfile
+	Removed bool   // true if the lockfile was removed, false if it was created or written
+}
+
+// LockfileWatcher watches lockfiles in directories, so that DFF does not have to poll for the League client
+type LockfileWatcher struct {
+	Events chan LockfileEvent
+
+	watcher   *fsnotify.Watcher
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// WatchLockfiles watches lockfiles in dirs. Directories which do not exist are skipped, and an error is
+// returned only if none of dirs can be watched. Events is closed once the watcher is closed.
+func WatchLockfiles(dirs []string) (*LockfileWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	watched := 0
+	for _, dir := range dirs {
+		if err = watcher.Add(dir); err == nil {
+			watched++
+		}
+	}
+	if watched == 0 {
+		_ = watcher.Close()
+		return nil, err
+	}
+
+	w := &LockfileWatcher{
+		Events:  make(chan LockfileEvent, 8),
+		watcher: watcher,
+		done:    make(chan struct{}),
+	}
+	go w.readEvents()
+
+	return w, nil
+}
+
+// readEvents forwards changes of lockfiles until the watcher is closed
+func (w *LockfileWatcher) readEvents() {
+	defer close(w.Events)
+
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Base(event.Name) != LockfileName {
+				continue
+			}
+			lockfileEvent := LockfileEvent{
+				Dir:     filepath.Dir(event.Name),
+				Removed: event.Op&(fsnotify.Remove|fsnotify.Rename) != 0,
+			}
+			select {
+			case w.Events <- lockfileEvent:
+			case <-w.done:
+				return
+			}
+		case _, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Close stops watching lockfiles
+func (w *LockfileWatcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.done)
+		err = w.watcher.Close()
+	})
+	return err
+}
@@ -0,0 +1,60 @@
+package lcu
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// nextLockfileEvent returns the next event of w, or fails the test after a second
+func nextLockfileEvent(t *testing.T, w *LockfileWatcher) LockfileEvent {
+	select {
+	case event, ok := <-w.Events:
+		if !ok {
+			t.Fatal("Events closed")
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("Timed out")
+	}
+	return LockfileEvent{}
+}
+
+func TestWatchLockfiles(t *testing.T) {
+	dir := t.TempDir()
+	w, err := WatchLockfiles([]string{filepath.Join(dir, "missing"), dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Other files are ignored
+	if err = ioutil.WriteFile(filepath.Join(dir, "other"), []byte("other"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, LockfileName), []byte("LeagueClient:1:2:3:https"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextLockfileEvent(t, w); event.Dir != dir || event.Removed {
+		t.Error("Incorrect event: ", event)
+	}
+
+	if err = os.Remove(filepath.Join(dir, LockfileName)); err != nil {
+		t.Fatal(err)
+	}
+	for event := nextLockfileEvent(t, w); !event.Removed; event = nextLockfileEvent(t, w) {
+		// Write events of the lockfile may arrive before removal
+	}
+
+	if err = w.Close(); err != nil {
+		t.Error(err)
+	}
+	// Events is closed after remaining events
+	for range w.Events {
+	}
+
+	if _, err = WatchLockfiles([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Error("Watching only missing directories should return an error")
+	}
+}
@@ -0,0 +1,112 @@
+// Package locale translates text shown to the user. English text is used as the key of translations,
+// so English does not need a translation file and untranslated text is shown in English.
+package locale
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// English is the language of text in the source code
+const English = "en_US"
+
+//go:embed *.json
+var files embed.FS
+
+var unknownLanguageError = errors.New("unknown language")
+
+// Translator translates English text to a language
+type Translator struct {
+	language     string
+	translations map[string]string
+	replacer     *strings.Replacer
+}
+
+// Load returns a Translator for language, e.g. "ko_KR". If language does not have translations,
+// a Translator which keeps English text is returned with unknownLanguageError.
+func Load(language string) (*Translator, error) {
+	if language == English {
+		return newTranslator(English, nil), nil
+	}
+
+	b, err := files.ReadFile(language + ".json")
+	if err != nil {
+		return newTranslator(English, nil), unknownLanguageError
+	}
+
+	var translations map[string]string
+	if err = json.Unmarshal(b, &translations); err != nil {
+		return newTranslator(English, nil), err
+	}
+
+	return newTranslator(language, translations), nil
+}
+
+// Languages returns languages with translations, including English
+func Languages() []string {
+	languages := []string{English}
+	entries, _ := files.ReadDir(".")
+	for _, entry := range entries {
+		languages = append(languages, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	return languages
+}
+
+func newTranslator(language string, translations map[string]string) *Translator {
+	// Longer text is replaced first, e.g. "Core Items" before "Items"
+	keys := make([]string, 0, len(translations))
+	for key := range translations {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+
+	pairs := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		pairs = append(pairs, key, translations[key])
+	}
+
+	return &Translator{
+		language:     language,
+		translations: translations,
+		replacer:     strings.NewReplacer(pairs...),
+	}
+}
+
+// Language returns the language of t
+func (t *Translator) Language() string {
+	return t.language
+}
+
+// T returns the translation of text, or text if it is not translated
+func (t *Translator) T(text string) string {
+	if translation, ok := t.translations[text]; ok {
+		return translation
+	}
+	return text
+}
+
+// Replace translates every translated phrase in text. It is used for text composed of
+// several phrases, e.g. "Starter Items (First 3 skills: Q -> W -> E)".
+func (t *Translator) Replace(text string) string {
+	return t.replacer.Replace(text)
+}
+
+// ReplaceAll returns a copy of texts with every text translated by Replace
+func (t *Translator) ReplaceAll(texts []string) []string {
+	if texts == nil {
+		return nil
+	}
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		translated[i] = t.Replace(text)
+	}
+	return translated
+}
@@ -0,0 +1,41 @@
+package locale
+
+import "testing"
+
+func TestLoad(t *testing.T) {
+	tr, err := Load("ko_KR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := tr.T("Boots"); text != "신발" {
+		t.Error("Incorrect translation: ", text)
+	}
+	if text := tr.T("Not translated"); text != "Not translated" {
+		t.Error("Untranslated text should be kept: ", text)
+	}
+	if text := tr.Replace("Core Items (Skill Tree: Q -> W -> E)"); text != "핵심 아이템 (스킬 트리: Q -> W -> E)" {
+		t.Error("Incorrect translation of phrases: ", text)
+	}
+	if text := tr.Replace("Partially updated. Runes: no valid rune page"); text != "일부만 설정됨. 룬: no valid rune page" {
+		t.Error("Incorrect translation of phrases: ", text)
+	}
+
+	if tr, err = Load("xx_XX"); err != unknownLanguageError || tr.Language() != English || tr.T("Boots") != "Boots" {
+		t.Error("Unknown language should use English: ", err)
+	}
+}
+
+func TestTranslationFiles(t *testing.T) {
+	for _, language := range Languages() {
+		tr, err := Load(language)
+		if err != nil {
+			t.Error("Could not load ", language, ": ", err)
+			continue
+		}
+		for key, translation := range tr.translations {
+			if translation == "" {
+				t.Error("Empty translation of ", key, " in ", language)
+			}
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package provider
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/pkg/log"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SnapshotVersion is used to keep track of snapshot file versions.
+// If Build or ChampionInfo is edited in any way, this value must be incremented.
+const SnapshotVersion uint16 = 3
+
+// legacySnapshotVersion is the last snapshot version without brackets. Its data is of defaultBracket.
+const legacySnapshotVersion uint16 = 2
+
+// defaultBracket is the bracket of the op.gg default region and tier
+const defaultBracket = "/"
+
+// snapshotChampionsFile is the name of the champion list file in the directory of a bracket.
+// Snapshots of legacySnapshotVersion have the file at the root.
+const snapshotChampionsFile = "champions.json"
+
+var incompatibleSnapshotError = errors.New("snapshot is incompatible")
+
+var otherBracketError = errors.New("snapshot is for another region or tier")
+
+type snapshotChampions struct {
+	Version      uint16         `json:"version"`
+	CreationTime time.Time      `json:"creation_time"`
+	Bracket      string         `json:"bracket"`
+	Champions    []ChampionInfo `json:"champions"`
+}
+
+// snapshotBracketDir returns the directory of data of bracket inside a snapshot (e.g. "builds/kr_diamond_plus")
+func snapshotBracketDir(bracket string) string {
+	return path.Join("builds", bracketDir(bracket))
+}
+
+// snapshotBuildPath returns the path of a build inside dir of a snapshot (e.g. "builds/kr_diamond_plus/0/103_Mid.json")
+func snapshotBuildPath(dir string, champId int, mode datatype.GameMode, position cache.Position) string {
+	name := strconv.Itoa(champId)
+	if position != cache.None {
+		name += "_" + position.String()
+	}
+	return path.Join(dir, strconv.Itoa(int(mode)), name+".json")
+}
+
+// bracketDir returns the directory name of bracket (e.g. "kr/diamond_plus" -> "kr_diamond_plus").
+// Empty region or tier is "default".
+func bracketDir(bracket string) string {
+	parts := strings.Split(bracket, "/")
+	for i := range parts {
+		if parts[i] == "" {
+			parts[i] = "default"
+		}
+	}
+	return strings.Join(parts, "_")
+}
+
+// IsArchive returns true if the snapshot at snapshotPath is a zip archive
+func IsArchive(snapshotPath string) bool {
+	return strings.EqualFold(filepath.Ext(snapshotPath), ".zip")
+}
+
+// Offline provides build data of a bracket from a snapshot directory or a zip archive of the directory.
+// Snapshots can be created with Recorder during an online session.
+type Offline struct {
+	files   fs.FS
+	closer  io.Closer
+	bracket string
+	dir     string // directory of data of bracket
+	legacy  bool   // true if the snapshot is of legacySnapshotVersion
+}
+
+// NewOffline opens a snapshot at snapshotPath, which is either a directory or a zip archive.
+// Only data of bracket (region and tier, e.g. "kr/diamond_plus") is provided. Snapshots of
+// legacySnapshotVersion are used as data of defaultBracket.
+func NewOffline(snapshotPath string, bracket string, logger *log.Logger) (offline *Offline, err error) {
+	offline = &Offline{bracket: bracket, dir: snapshotBracketDir(bracket)}
+
+	if IsArchive(snapshotPath) {
+		var reader *zip.ReadCloser
+		if reader, err = zip.OpenReader(snapshotPath); err != nil {
+			return nil, err
+		}
+		offline.files = reader
+		offline.closer = reader
+	} else {
+		if _, err = os.Stat(snapshotPath); err != nil {
+			return nil, err
+		}
+		offline.files = os.DirFS(snapshotPath)
+	}
+
+	if _, err = fs.Stat(offline.files, path.Join(offline.dir, snapshotChampionsFile)); err == nil {
+		return offline, nil
+	}
+
+	var champions snapshotChampions
+	if err = offline.readJson(snapshotChampionsFile, &champions); err == nil && champions.Version == legacySnapshotVersion {
+		if bracketDir(bracket) == bracketDir(defaultBracket) {
+			logger.Info("Using snapshot of version ", legacySnapshotVersion, " as data of the default region and tier")
+			offline.dir = "builds"
+			offline.legacy = true
+			return offline, nil
+		}
+		logger.Warning("Snapshot at ", snapshotPath, " only has data of the default region and tier. ",
+			"Record the snapshot again with save_snapshot to use it with ", bracket)
+	}
+
+	_ = offline.Close()
+	return nil, otherBracketError
+}
+
+// Close closes the snapshot archive, if any
+func (o *Offline) Close() error {
+	if o.closer != nil {
+		return o.closer.Close()
+	}
+	return nil
+}
+
+// ChampionList implements BuildProvider
+func (o *Offline) ChampionList() ([]ChampionInfo, error) {
+	var champions snapshotChampions
+	if o.legacy {
+		if err := o.readJson(snapshotChampionsFile, &champions); err != nil {
+			return nil, err
+		}
+		if champions.Version != legacySnapshotVersion {
+			return nil, incompatibleSnapshotError
+		}
+		return champions.Champions, nil
+	}
+
+	if err := o.readJson(path.Join(o.dir, snapshotChampionsFile), &champions); err != nil {
+		return nil, err
+	}
+	if champions.Version != SnapshotVersion {
+		return nil, incompatibleSnapshotError
+	}
+	if champions.Bracket != o.bracket {
+		return nil, otherBracketError
+	}
+
+	return champions.Champions, nil
+}
+
+// Build implements BuildProvider
+func (o *Offline) Build(champion *datatype.Champion, mode datatype.GameMode, position cache.Position) (build *Build, err error) {
+	if err = o.readJson(snapshotBuildPath(o.dir, champion.ID, mode, position), &build); err != nil {
+		return nil, err
+	}
+	return build, nil
+}
+
+func (o *Offline) readJson(name string, v interface{}) error {
+	b, err := fs.ReadFile(o.files, name)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// Recorder is a BuildProvider which saves every result of another BuildProvider to a snapshot directory
+type Recorder struct {
+	provider BuildProvider
+	dir      string
+	bracket  string
+	log      *log.Logger
+}
+
+// NewRecorder creates a Recorder which saves results of buildProvider to dir as data of bracket
+func NewRecorder(buildProvider BuildProvider, dir string, bracket string, logger *log.Logger) *Recorder {
+	return &Recorder{
+		provider: buildProvider,
+		dir:      dir,
+		bracket:  bracket,
+		log:      logger,
+	}
+}
+
+// ChampionList implements BuildProvider
+func (r *Recorder) ChampionList() (champions []ChampionInfo, err error) {
+	if champions, err = r.provider.ChampionList(); err != nil {
+		return nil, err
+	}
+
+	r.save(path.Join(snapshotBracketDir(r.bracket), snapshotChampionsFile), &snapshotChampions{
+		Version:      SnapshotVersion,
+		CreationTime: time.Now(),
+		Bracket:      r.bracket,
+		Champions:    champions,
+	})
+
+	return champions, nil
+}
+
+// Build implements BuildProvider
+func (r *Recorder) Build(champion *datatype.Champion, mode datatype.GameMode, position cache.Position) (build *Build, err error) {
+	if build, err = r.provider.Build(champion, mode, position); err != nil {
+		return nil, err
+	}
+
+	r.save(snapshotBuildPath(snapshotBracketDir(r.bracket), champion.ID, mode, position), build)
+
+	return build, nil
+}
+
+// MatchupBuild implements MatchupProvider. Matchups are not saved to the snapshot.
+func (r *Recorder) MatchupBuild(champion *datatype.Champion, opponent *datatype.Champion, mode datatype.GameMode, position cache.Position) (*Build, error) {
+	matchupProvider, ok := r.provider.(MatchupProvider)
+	if !ok {
+		return nil, unsupportedMatchupError
+	}
+	return matchupProvider.MatchupBuild(champion, opponent, mode, position)
+}
+
+// save writes v to name in the snapshot directory. Failing to save is not fatal.
+func (r *Recorder) save(name string, v interface{}) {
+	filename := filepath.Join(r.dir, filepath.FromSlash(name))
+
+	b, err := json.MarshalIndent(v, "", "\t")
+	if err == nil {
+		if err = os.MkdirAll(filepath.Dir(filename), 0700); err == nil {
+			err = ioutil.WriteFile(filename, b, 0644)
+		}
+	}
+
+	if err != nil {
+		r.log.Debug(err)
+		r.log.Warning("Could not save snapshot: ", name)
+	}
+}
+
+// Fallback is a BuildProvider which uses the secondary BuildProvider if the primary one fails
+type Fallback struct {
+	primary   BuildProvider
+	secondary BuildProvider
+	log       *log.Logger
+}
+
+// NewFallback creates a Fallback which uses secondary if primary fails
+func NewFallback(primary BuildProvider, secondary BuildProvider, logger *log.Logger) *Fallback {
+	return &Fallback{
+		primary:   primary,
+		secondary: secondary,
+		log:       logger,
+	}
+}
+
+// ChampionList implements BuildProvider
+func (f *Fallback) ChampionList() (champions []ChampionInfo, err error) {
+	if champions, err = f.primary.ChampionList(); err == nil {
+		return champions, nil
+	}
+	f.log.Debug(err)
+	f.log.Warning("Could not get champion list, using offline data")
+
+	return f.secondary.ChampionList()
+}
+
+// Build implements BuildProvider
+func (f *Fallback) Build(champion *datatype.Champion, mode datatype.GameMode, position cache.Position) (build *Build, err error) {
+	if build, err = f.primary.Build(champion, mode, position); err == nil {
+		return build, nil
+	}
+	f.log.Debug(err)
+	f.log.Warning("Could not get build data of ", champion.Alias, ", using offline data")
+
+	return f.secondary.Build(champion, mode, position)
+}
+
+// MatchupBuild implements MatchupProvider. Offline data does not have matchups, so only the primary is used.
+func (f *Fallback) MatchupBuild(champion *datatype.Champion, opponent *datatype.Champion, mode datatype.GameMode, position cache.Position) (*Build, error) {
+	matchupProvider, ok := f.primary.(MatchupProvider)
+	if !ok {
+		return nil, unsupportedMatchupError
+	}
+	return matchupProvider.MatchupBuild(champion, opponent, mode, position)
+}
@@ -0,0 +1,202 @@
+package provider
+
+import (
+	"archive/zip"
+	"errors"
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/pkg/log"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var unavailableError = errors.New("provider unavailable")
+
+// stubProvider returns fixed data, or unavailableError if unavailable is true
+type stubProvider struct {
+	unavailable bool
+}
+
+func (s *stubProvider) ChampionList() ([]ChampionInfo, error) {
+	if s.unavailable {
+		return nil, unavailableError
+	}
+	return []ChampionInfo{{ID: 103, Positions: []PositionInfo{{Position: cache.Mid, RoleRate: 0.9}}}}, nil
+}
+
+func (s *stubProvider) Build(champion *datatype.Champion, mode datatype.GameMode, position cache.Position) (*Build, error) {
+	if s.unavailable {
+		return nil, unavailableError
+	}
+	return &Build{
+		Source:         "stub/" + champion.Alias + "/" + position.String(),
+		RunePages:      []RuneOption{{Stats: Stats{Play: 10, Win: 6}, PrimaryStyleID: 8100, PrimaryRuneIds: []int{8112}}},
+		SummonerSpells: []SpellOption{{Ids: []int{4, 14}}},
+	}, nil
+}
+
+// zipDir writes every file in dir to a zip archive at filename
+func zipDir(t *testing.T, dir string, filename string) {
+	out, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.Close()
+
+	w := zip.NewWriter(out)
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		f, err := w.Create(filepath.ToSlash(rel))
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(b)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	logger := log.NewLogger(ioutil.Discard, log.DEBUG, "")
+	dir := filepath.Join(t.TempDir(), "snapshot")
+	champion := &datatype.Champion{ID: 103, Alias: "Ahri"}
+
+	recorder := NewRecorder(&stubProvider{}, dir, "kr/diamond_plus", logger)
+	expectedChampions, err := recorder.ChampionList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedBuild, err := recorder.Build(champion, datatype.Default, cache.Mid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	archive := filepath.Join(t.TempDir(), "snapshot.zip")
+	zipDir(t, dir, archive)
+
+	for _, snapshotPath := range []string{dir, archive} {
+		offline, err := NewOffline(snapshotPath, "kr/diamond_plus", logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		champions, err := offline.ChampionList()
+		if err != nil || !reflect.DeepEqual(champions, expectedChampions) {
+			t.Error("Incorrect champion list from ", snapshotPath, ": ", err)
+		}
+
+		build, err := offline.Build(champion, datatype.Default, cache.Mid)
+		if err != nil || !reflect.DeepEqual(build, expectedBuild) {
+			t.Error("Incorrect build from ", snapshotPath, ": ", err)
+		}
+
+		if _, err = offline.Build(champion, datatype.Aram, cache.None); err == nil {
+			t.Error("Build not in the snapshot should return an error")
+		}
+
+		if err = offline.Close(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// Data of another bracket is not used
+	if _, err = NewOffline(dir, "/", logger); err != otherBracketError {
+		t.Error("Snapshot without data of the bracket should not be used: ", err)
+	}
+
+	// Brackets recorded to the same snapshot are kept
+	if _, err = NewRecorder(&stubProvider{}, dir, "/", logger).ChampionList(); err != nil {
+		t.Fatal(err)
+	}
+	for _, bracket := range []string{"kr/diamond_plus", "/"} {
+		offline, err := NewOffline(dir, bracket, logger)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = offline.ChampionList(); err != nil {
+			t.Error("Champion list of ", bracket, " is not available: ", err)
+		}
+	}
+
+	if _, err = NewOffline(filepath.Join(t.TempDir(), "missing"), "/", logger); err == nil {
+		t.Error("Opening a missing snapshot should return an error")
+	}
+}
+
+func TestLegacySnapshot(t *testing.T) {
+	logger := log.NewLogger(ioutil.Discard, log.DEBUG, "")
+	dir := t.TempDir()
+	champion := &datatype.Champion{ID: 103, Alias: "Ahri"}
+
+	// Snapshots of version 2 have the champion list at the root and builds without a bracket directory
+	files := map[string]string{
+		snapshotChampionsFile:   `{"version": 2, "champions": [{"id": 103}]}`,
+		"builds/0/103_Mid.json": `{"source": "legacy"}`,
+		"builds/450/103.json":   `{"source": "legacy aram"}`,
+	}
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	offline, err := NewOffline(dir, defaultBracket, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if champions, err := offline.ChampionList(); err != nil || len(champions) != 1 || champions[0].ID != 103 {
+		t.Error("Incorrect champion list of a legacy snapshot: ", champions, err)
+	}
+	if build, err := offline.Build(champion, datatype.Default, cache.Mid); err != nil || build.Source != "legacy" {
+		t.Error("Incorrect build of a legacy snapshot: ", build, err)
+	}
+
+	if _, err = NewOffline(dir, "kr/diamond_plus", logger); err != otherBracketError {
+		t.Error("Legacy snapshot should only be used for the default bracket: ", err)
+	}
+}
+
+func TestFallback(t *testing.T) {
+	logger := log.NewLogger(ioutil.Discard, log.DEBUG, "")
+	champion := &datatype.Champion{ID: 103, Alias: "Ahri"}
+
+	fallback := NewFallback(&stubProvider{unavailable: true}, &stubProvider{}, logger)
+	if build, err := fallback.Build(champion, datatype.Default, cache.Mid); err != nil || build.Source != "stub/Ahri/Mid" {
+		t.Error("Incorrect result for Fallback.Build: ", err)
+	}
+	if _, err := fallback.ChampionList(); err != nil {
+		t.Error(err)
+	}
+
+	fallback = NewFallback(&stubProvider{unavailable: true}, &stubProvider{unavailable: true}, logger)
+	if _, err := fallback.Build(champion, datatype.Default, cache.Mid); err != unavailableError {
+		t.Error("Incorrect result for Fallback.Build: ", err)
+	}
+
+	opponent := &datatype.Champion{ID: 238, Alias: "Zed"}
+	if _, err := fallback.MatchupBuild(champion, opponent, datatype.Default, cache.Mid); err != unsupportedMatchupError {
+		t.Error("Incorrect result for Fallback.MatchupBuild: ", err)
+	}
+}
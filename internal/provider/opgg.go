@@ -0,0 +1,348 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/anaskhan96/soup"
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+	"github.com/jaeha-choi/DFF/pkg/log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var unknownPositionError = errors.New("unknown position")
+
+var noPageDataError = errors.New("op.gg page does not have build data")
+
+// OPGGRegions are regions of op.gg statistics. Empty region uses the op.gg default.
+var OPGGRegions = []string{"", "global", "na", "euw", "eune", "kr", "jp", "br", "lan", "las", "oce", "ru", "tr", "ph", "sg", "th", "tw", "vn", "me"}
+
+// OPGGTiers are tier brackets of op.gg statistics. Empty tier uses the op.gg default.
+var OPGGTiers = []string{"", "all", "iron_plus", "bronze_plus", "silver_plus", "gold_plus", "platinum_plus", "emerald_plus",
+	"diamond_plus", "master_plus", "master", "grandmaster", "challenger"}
+
+// opggOptions has the same structure as item/spell lists of datatype.OPGGChampData
+type opggOptions = []struct {
+	Ids      []int   `json:"ids"`
+	Win      int     `json:"win"`
+	Play     int     `json:"play"`
+	PickRate float64 `json:"pick_rate"`
+}
+
+// OPGG provides build data scraped from op.gg
+type OPGG struct {
+	Language string
+	// Region and Tier filter statistics. Empty values use op.gg defaults.
+	Region string
+	Tier   string
+	log    *log.Logger
+}
+
+// NewOPGG creates a BuildProvider for op.gg. language is used for the locale of op.gg pages,
+// region and tier are used to filter statistics.
+func NewOPGG(logger *log.Logger, language string, region string, tier string) *OPGG {
+	return &OPGG{
+		Language: language,
+		Region:   region,
+		Tier:     tier,
+		log:      logger,
+	}
+}
+
+// IsOPGGRegion returns true if region is one of OPGGRegions
+func IsOPGGRegion(region string) bool {
+	return contains(OPGGRegions, region)
+}
+
+// IsOPGGTier returns true if tier is one of OPGGTiers
+func IsOPGGTier(tier string) bool {
+	return contains(OPGGTiers, tier)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// filterUrl returns the url of an op.gg page with region and tier filters. query may be nil.
+func (o *OPGG) filterUrl(base string, query url.Values) string {
+	if query == nil {
+		query = url.Values{}
+	}
+	if o.Region != "" {
+		query.Set("region", o.Region)
+	}
+	if o.Tier != "" {
+		query.Set("tier", o.Tier)
+	}
+	if len(query) == 0 {
+		return base
+	}
+	return base + "?" + query.Encode()
+}
+
+// ChampionList implements BuildProvider
+func (o *OPGG) ChampionList() (champions []ChampionInfo, err error) {
+	data, err := o.getFromJson(o.filterUrl("https://na.op.gg/champions", nil), opggChampionListSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	champList := data.Props.PageProps.ChampionMetaList
+	champions = make([]ChampionInfo, len(champList))
+
+	for i, champ := range champList {
+		champions[i] = ChampionInfo{
+			ID:        champ.ID,
+			IsRip:     champ.IsRip,
+			Positions: make([]PositionInfo, len(champ.Positions)),
+		}
+		for j, position := range champ.Positions {
+			if champions[i].Positions[j].Position, err = parsePosition(position.Name); err != nil {
+				o.log.Debug("Role not found: ", position.Name)
+				return nil, err
+			}
+			champions[i].Positions[j].RoleRate = position.Stats.RoleRate
+		}
+	}
+
+	return champions, nil
+}
+
+// Build implements BuildProvider
+func (o *OPGG) Build(champion *datatype.Champion, mode datatype.GameMode, position cache.Position) (*Build, error) {
+	var pageUrl string
+
+	// Only modes with their own build data are supported
+	info := datatype.LookupMode(mode)
+	if info == nil || info.BuildMode != mode {
+		return nil, unsupportedModeError
+	}
+
+	if info.HasPositions() {
+		pageUrl = "https://op.gg/champions/" + champion.Alias + "/" + position.String() + "/build"
+	} else {
+		pageUrl = "https://na.op.gg/" + info.DataPath + "/" + champion.Alias + "/build"
+	}
+
+	return o.build(o.filterUrl(pageUrl, nil))
+}
+
+// MatchupBuild implements MatchupProvider. Only modes with positions have matchups.
+func (o *OPGG) MatchupBuild(champion *datatype.Champion, opponent *datatype.Champion, mode datatype.GameMode, position cache.Position) (*Build, error) {
+	info := datatype.LookupMode(mode)
+	if info == nil || info.BuildMode != mode || !info.HasPositions() {
+		return nil, unsupportedModeError
+	}
+
+	return o.build(o.filterUrl("https://op.gg/champions/"+champion.Alias+"/"+position.String()+"/build",
+		url.Values{"target_champion": {opponent.Alias}}))
+}
+
+// build downloads a champion build page at url and converts it to Build
+func (o *OPGG) build(url string) (build *Build, err error) {
+	page, err := o.fetch(url)
+	if err != nil {
+		return nil, err
+	}
+
+	build, missingOptional, err := ParseOPGGBuild(page)
+	o.warnMissing(url, missingOptional)
+	if err != nil {
+		o.log.Debug(err)
+		o.log.Error("Unexpected data from ", url)
+		return nil, err
+	}
+	build.Source = url
+
+	return build, nil
+}
+
+// fetch downloads an op.gg page
+func (o *OPGG) fetch(url string) (page string, err error) {
+	soup.Cookie("customLocale", o.Language)
+
+	retryCnt := 3
+	for i := 0; i < retryCnt; i++ {
+		page, err = soup.Get(url)
+		if err == nil {
+			break
+		} else if i == retryCnt-1 {
+			o.log.Debug(err)
+			o.log.Error("Couldn't connect to the given url", url)
+			return "", err
+		}
+		o.log.Debug(err)
+		time.Sleep(500 * time.Millisecond)
+		o.log.Debug("Retrying..")
+	}
+
+	return page, nil
+}
+
+// getFromJson downloads an op.gg page and decodes data embedded in the page. Fields in schema must exist.
+func (o *OPGG) getFromJson(url string, schema []schemaField) (r *datatype.OPGGResponse, err error) {
+	page, err := o.fetch(url)
+	if err != nil {
+		return nil, err
+	}
+
+	r, missingOptional, err := decodeOPGGPage(page, schema)
+	o.warnMissing(url, missingOptional)
+	if err != nil {
+		o.log.Debug(err)
+		o.log.Error("Unexpected data from ", url)
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// warnMissing logs optional fields missing from the page at url
+func (o *OPGG) warnMissing(url string, missingOptional []string) {
+	if len(missingOptional) > 0 {
+		o.log.Warning("Some data is missing from ", url, ": ", strings.Join(missingOptional, ", "))
+	}
+}
+
+// decodeOPGGPage decodes data embedded in an op.gg page. Fields in schema are checked before decoding,
+// so that layout changes of op.gg are reported as SchemaError instead of empty or broken builds.
+func decodeOPGGPage(page string, schema []schemaField) (r *datatype.OPGGResponse, missingOptional []string, err error) {
+	doc := soup.HTMLParse(page)
+	if doc.Error != nil {
+		return nil, nil, doc.Error
+	}
+	script := doc.Find("script", "id", "__NEXT_DATA__")
+	if script.Error != nil {
+		return nil, nil, noPageDataError
+	}
+	b := []byte(script.Text())
+
+	var raw interface{}
+	if err = json.Unmarshal(b, &raw); err != nil {
+		return nil, nil, err
+	}
+	missing, missingOptional := checkSchema(raw, schema)
+	if len(missing) > 0 {
+		return nil, missingOptional, &SchemaError{Missing: missing}
+	}
+
+	if err = json.Unmarshal(b, &r); err != nil {
+		return nil, missingOptional, err
+	}
+
+	return r, missingOptional, nil
+}
+
+// ParseOPGGBuild converts a champion build page of op.gg to Build. Optional fields missing from
+// the page (e.g. skill orders) are returned with the build.
+func ParseOPGGBuild(page string) (build *Build, missingOptional []string, err error) {
+	data, missingOptional, err := decodeOPGGPage(page, opggBuildSchema)
+	if err != nil {
+		return nil, missingOptional, err
+	}
+	return convertOPGGChampData(&data.Props.PageProps.Data), missingOptional, nil
+}
+
+func parsePosition(name string) (cache.Position, error) {
+	switch name {
+	case "TOP":
+		return cache.Top, nil
+	case "JUNGLE":
+		return cache.Jungle, nil
+	case "MID":
+		return cache.Mid, nil
+	case "ADC":
+		return cache.Adc, nil
+	case "SUPPORT":
+		return cache.Support, nil
+	default:
+		return cache.None, unknownPositionError
+	}
+}
+
+// convertOPGGChampData converts op.gg build data to Build
+func convertOPGGChampData(data *datatype.OPGGChampData) *Build {
+	build := &Build{
+		RunePages:      make([]RuneOption, 0, len(data.RunePages)),
+		StarterItems:   convertItemOptions(data.StarterItems),
+		CoreItems:      convertItemOptions(data.CoreItems),
+		Boots:          convertItemOptions(data.Boots),
+		LastItems:      convertItemOptions(data.LastItems),
+		SummonerSpells: make([]SpellOption, len(data.SummonerSpells)),
+		Skills:         make([]SkillOption, len(data.Skills)),
+		SkillMasteries: make([]SkillMastery, len(data.SkillMasteries)),
+	}
+
+	for _, page := range data.RunePages {
+		// Rune pages without any build cannot be applied
+		if len(page.Builds) == 0 {
+			continue
+		}
+		build.RunePages = append(build.RunePages, RuneOption{
+			Stats:            Stats{Play: page.Play, Win: page.Win, PickRate: page.PickRate},
+			PrimaryStyleID:   page.PrimaryPageID,
+			SubStyleID:       page.SecondaryPageID,
+			PrimaryRuneIds:   page.Builds[0].PrimaryRuneIds,
+			SecondaryRuneIds: page.Builds[0].SecondaryRuneIds,
+			StatModIds:       page.Builds[0].StatModIds,
+		})
+	}
+
+	for i, spell := range data.SummonerSpells {
+		build.SummonerSpells[i] = SpellOption{
+			Stats: Stats{Play: spell.Play, Win: spell.Win, PickRate: spell.PickRate},
+			Ids:   spell.Ids,
+		}
+	}
+
+	for i, skill := range data.Skills {
+		build.Skills[i] = SkillOption{
+			Stats: Stats{Play: skill.Play, Win: skill.Win, PickRate: skill.PickRate},
+			Order: skill.Order,
+		}
+	}
+
+	// Opponents are grouped by op.gg, e.g. by position
+	for _, group := range data.Summary.Opponents {
+		for _, opponent := range group {
+			build.Opponents = append(build.Opponents, OpponentStats{
+				Stats:      Stats{Play: opponent.Play, Win: opponent.Win},
+				ChampionID: opponent.ChampionID,
+			})
+		}
+	}
+
+	for i, mastery := range data.SkillMasteries {
+		build.SkillMasteries[i] = SkillMastery{
+			Stats:  Stats{Play: mastery.Play, Win: mastery.Win, PickRate: mastery.PickRate},
+			Ids:    mastery.Ids,
+			Builds: make([]SkillOption, len(mastery.Builds)),
+		}
+		for j, order := range mastery.Builds {
+			build.SkillMasteries[i].Builds[j] = SkillOption{
+				Stats: Stats{Play: order.Play, Win: order.Win, PickRate: order.PickRate},
+				Order: order.Order,
+			}
+		}
+	}
+
+	return build
+}
+
+func convertItemOptions(options opggOptions) []ItemOption {
+	items := make([]ItemOption, len(options))
+	for i, option := range options {
+		items[i] = ItemOption{
+			Stats: Stats{Play: option.Play, Win: option.Win, PickRate: option.PickRate},
+			Ids:   option.Ids,
+		}
+	}
+	return items
+}
@@ -0,0 +1,110 @@
+package provider
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// opggPage wraps data in an op.gg page
+func opggPage(data string) string {
+	return `<html><body><div id="__next"></div><script id="__NEXT_DATA__" type="application/json">` +
+		`{"props": {"pageProps": {"data": ` + data + `}}}</script></body></html>`
+}
+
+const opggBuildData = `{
+	"rune_pages": [{"primary_page_id": 8100, "secondary_page_id": 8300, "play": 10, "win": 6, "pick_rate": 0.5,
+		"builds": [{"primary_rune_ids": [8112, 8139, 8138, 8135], "secondary_rune_ids": [8345, 8347], "stat_mod_ids": [5008, 5008, 5002]}]}],
+	"starter_items": [{"ids": [1056, 2003]}],
+	"core_items": [{"ids": [6655, 3020, 4645], "play": 10, "win": 5}],
+	"boots": [{"ids": [3020]}],
+	"last_items": null,
+	"summoner_spells": [{"ids": [4, 14]}],
+	"skills": [{"order": ["Q", "W", "E"]}],
+	"skill_masteries": [{"ids": ["Q", "W", "E"], "builds": [{"order": ["Q", "W", "E"]}]}],
+	"summary": {"opponents": [[{"champion_id": 238, "play": 20, "win": 9}], [{"champion_id": 7, "play": 10, "win": 6}]]}
+}`
+
+func TestParseOPGGBuild(t *testing.T) {
+	build, missingOptional, err := ParseOPGGBuild(opggPage(opggBuildData))
+	if err != nil || len(missingOptional) != 0 {
+		t.Fatal(err, missingOptional)
+	}
+	if len(build.RunePages) != 1 || build.RunePages[0].PrimaryStyleID != 8100 || len(build.RunePages[0].StatModIds) != 3 ||
+		len(build.CoreItems) != 1 || build.CoreItems[0].WinRate() != 50 || len(build.LastItems) != 0 ||
+		len(build.SkillMasteries) != 1 || len(build.SkillMasteries[0].Builds) != 1 {
+		t.Error("Incorrect build: ", build)
+	}
+	if len(build.Opponents) != 2 || build.Opponents[0].ChampionID != 238 || build.Opponents[1].WinRate() != 60 {
+		t.Error("Incorrect opponents: ", build.Opponents)
+	}
+}
+
+func TestParseOPGGBuildSchemaDrift(t *testing.T) {
+	// rune_pages[].builds is renamed and skills are removed
+	data := `{
+		"rune_pages": [{"primary_page_id": 8100, "secondary_page_id": 8300, "play": 10, "win": 6, "pick_rate": 0.5, "rune_builds": []}],
+		"starter_items": [], "core_items": [], "boots": [], "last_items": [], "summoner_spells": [{"ids": [4, 14]}],
+		"skill_masteries": [{"ids": ["Q", "W", "E"]}]
+	}`
+	_, missingOptional, err := ParseOPGGBuild(opggPage(data))
+
+	schemaErr, ok := err.(*SchemaError)
+	if !ok {
+		t.Fatal("Incorrect error: ", err)
+	}
+	expected := []string{
+		"props.pageProps.data.rune_pages[].builds[].primary_rune_ids",
+		"props.pageProps.data.rune_pages[].builds[].secondary_rune_ids",
+		"props.pageProps.data.rune_pages[].builds[].stat_mod_ids",
+	}
+	if !reflect.DeepEqual(schemaErr.Missing, expected) {
+		t.Error("Incorrect missing fields: ", schemaErr.Missing)
+	}
+	expected = []string{
+		"props.pageProps.data.skills[].order",
+		"props.pageProps.data.skill_masteries[].builds[].order",
+	}
+	if !reflect.DeepEqual(missingOptional, expected) {
+		t.Error("Incorrect missing optional fields: ", missingOptional)
+	}
+
+	// Build data is moved out of the page
+	if _, _, err = ParseOPGGBuild(`<html><body><script id="other"></script></body></html>`); err != noPageDataError {
+		t.Error("Incorrect error for a page without data: ", err)
+	}
+	if _, _, err = ParseOPGGBuild(opggPage(`"not an object"`)); err == nil {
+		t.Error("Data of a wrong type should be rejected")
+	}
+}
+
+func TestDecodeOPGGChampionList(t *testing.T) {
+	page := `<html><body><script id="__NEXT_DATA__" type="application/json">{"props": {"pageProps": {"championMetaList": [
+		{"id": 103, "positions": [{"name": "MID", "stats": {"role_rate": 0.9}}]},
+		{"id": 99, "positions": null},
+		{"id": 1, "positions": [{"name": "MID", "stats": {"roleRate": 0.9}}]}
+	]}}}</script></body></html>`
+
+	_, _, err := decodeOPGGPage(page, opggChampionListSchema)
+	if schemaErr, ok := err.(*SchemaError); !ok ||
+		!reflect.DeepEqual(schemaErr.Missing, []string{"props.pageProps.championMetaList[].positions[].stats.role_rate"}) {
+		t.Error("Incorrect error: ", err)
+	}
+}
+
+func TestFilterUrl(t *testing.T) {
+	o := NewOPGG(nil, "en_US", "", "")
+	if pageUrl := o.filterUrl("https://op.gg/champions", nil); pageUrl != "https://op.gg/champions" {
+		t.Error("Incorrect url without filters: ", pageUrl)
+	}
+
+	o = NewOPGG(nil, "en_US", "kr", "diamond_plus")
+	pageUrl := o.filterUrl("https://op.gg/champions/Ahri/mid/build", url.Values{"target_champion": {"Zed"}})
+	if pageUrl != "https://op.gg/champions/Ahri/mid/build?region=kr&target_champion=Zed&tier=diamond_plus" {
+		t.Error("Incorrect url with filters: ", pageUrl)
+	}
+
+	if !IsOPGGRegion("euw") || IsOPGGRegion("EUW1") || !IsOPGGTier("") || IsOPGGTier("plat+") {
+		t.Error("Incorrect validation of regions and tiers")
+	}
+}
@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"errors"
+	"github.com/jaeha-choi/DFF/internal/cache"
+	"github.com/jaeha-choi/DFF/internal/datatype"
+)
+
+var unsupportedModeError = errors.New("game mode is not supported by the provider")
+
+var unsupportedMatchupError = errors.New("matchups are not supported by the provider")
+
+// BuildProvider provides build data used to create rune pages, item sets and spells
+type BuildProvider interface {
+	// ChampionList returns every champion known to the provider with its positions
+	ChampionList() ([]ChampionInfo, error)
+
+	// Build returns build data of a champion for the given game mode and position.
+	// position is ignored if the game mode does not have positions.
+	Build(champion *datatype.Champion, mode datatype.GameMode, position cache.Position) (*Build, error)
+}
+
+// MatchupProvider is a BuildProvider which also provides build data against a specific opponent
+type MatchupProvider interface {
+	BuildProvider
+
+	// MatchupBuild returns build data of a champion against opponent in the same lane
+	MatchupBuild(champion *datatype.Champion, opponent *datatype.Champion, mode datatype.GameMode, position cache.Position) (*Build, error)
+}
+
+type ChampionInfo struct {
+	ID        int
+	IsRip     bool
+	Positions []PositionInfo
+}
+
+type PositionInfo struct {
+	Position cache.Position
+	RoleRate float64
+}
+
+// Stats holds statistics of a single build option
+type Stats struct {
+	Play     int
+	Win      int
+	PickRate float64
+}
+
+// WinRate returns win rate in percent
+func (s Stats) WinRate() float64 {
+	if s.Play == 0 {
+		return 0
+	}
+	return float64(s.Win) / float64(s.Play) * 100
+}
+
+type RuneOption struct {
+	Stats
+	PrimaryStyleID   int
+	SubStyleID       int
+	PrimaryRuneIds   []int
+	SecondaryRuneIds []int
+	StatModIds       []int
+}
+
+// OpponentStats holds statistics of a champion against an opponent
+type OpponentStats struct {
+	Stats
+	ChampionID int
+}
+
+type ItemOption struct {
+	Stats
+	Ids []int
+}
+
+type SpellOption struct {
+	Stats
+	Ids []int
+}
+
+type SkillOption struct {
+	Stats
+	Order []string
+}
+
+// SkillMastery is the order of skills to max, with the most common leveling orders
+type SkillMastery struct {
+	Stats
+	Ids    []string
+	Builds []SkillOption
+}
+
+// Build is a provider-neutral build data of a champion. Options are sorted by preference.
+type Build struct {
+	Source string
+
+	RunePages      []RuneOption
+	StarterItems   []ItemOption
+	CoreItems      []ItemOption
+	Boots          []ItemOption
+	LastItems      []ItemOption
+	SummonerSpells []SpellOption
+	Skills         []SkillOption
+	SkillMasteries []SkillMastery
+	Opponents      []OpponentStats
+}
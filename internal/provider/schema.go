@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"strings"
+)
+
+// SchemaError is returned if fields used by DFF are missing from op.gg data,
+// which usually means op.gg changed the layout of its pages
+type SchemaError struct {
+	Missing []string
+}
+
+// Error implements the error interface.
+func (e *SchemaError) Error() string {
+	return "op.gg data is missing expected fields: " + strings.Join(e.Missing, ", ")
+}
+
+// schemaField is a path to a field of op.gg data used by DFF, e.g. "props.pageProps.data.rune_pages[].builds".
+// "[]" means every element of the array must have the rest of the path. Empty or null arrays are allowed.
+type schemaField struct {
+	path string
+	// optional fields are reported, but do not make the data unusable
+	optional bool
+}
+
+// opggBuildSchema is fields of champion build pages used by convertOPGGChampData
+var opggBuildSchema = []schemaField{
+	{path: "props.pageProps.data.rune_pages[].primary_page_id"},
+	{path: "props.pageProps.data.rune_pages[].secondary_page_id"},
+	{path: "props.pageProps.data.rune_pages[].play"},
+	{path: "props.pageProps.data.rune_pages[].win"},
+	{path: "props.pageProps.data.rune_pages[].pick_rate"},
+	{path: "props.pageProps.data.rune_pages[].builds[].primary_rune_ids"},
+	{path: "props.pageProps.data.rune_pages[].builds[].secondary_rune_ids"},
+	{path: "props.pageProps.data.rune_pages[].builds[].stat_mod_ids"},
+	{path: "props.pageProps.data.starter_items[].ids"},
+	{path: "props.pageProps.data.core_items[].ids"},
+	{path: "props.pageProps.data.core_items[].play"},
+	{path: "props.pageProps.data.core_items[].win"},
+	{path: "props.pageProps.data.boots[].ids"},
+	{path: "props.pageProps.data.last_items[].ids"},
+	{path: "props.pageProps.data.summoner_spells[].ids"},
+	{path: "props.pageProps.data.skills[].order", optional: true},
+	{path: "props.pageProps.data.skill_masteries[].ids", optional: true},
+	{path: "props.pageProps.data.skill_masteries[].builds[].order", optional: true},
+}
+
+// opggChampionListSchema is fields of the champion list page used by OPGG.ChampionList
+var opggChampionListSchema = []schemaField{
+	{path: "props.pageProps.championMetaList[].id"},
+	{path: "props.pageProps.championMetaList[].positions[].name"},
+	{path: "props.pageProps.championMetaList[].positions[].stats.role_rate"},
+}
+
+// checkSchema returns paths of fields in schema which are missing from data, decoded from JSON.
+// Missing optional fields are returned separately.
+func checkSchema(data interface{}, schema []schemaField) (missing []string, missingOptional []string) {
+	for _, field := range schema {
+		if hasPath(data, strings.Split(field.path, ".")) {
+			continue
+		}
+		if field.optional {
+			missingOptional = append(missingOptional, field.path)
+		} else {
+			missing = append(missing, field.path)
+		}
+	}
+	return missing, missingOptional
+}
+
+// hasPath returns true if value has the field at path
+func hasPath(value interface{}, path []string) bool {
+	if len(path) == 0 {
+		return true
+	}
+
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	name := strings.TrimSuffix(path[0], "[]")
+	child, exist := obj[name]
+	if !exist {
+		return false
+	}
+
+	if name == path[0] {
+		return hasPath(child, path[1:])
+	}
+
+	// Every element of the array must have the rest of the path
+	if child == nil {
+		return true
+	}
+	elems, ok := child.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, elem := range elems {
+		if !hasPath(elem, path[1:]) {
+			return false
+		}
+	}
+	return true
+}